@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
+)
+
+const keygenUsage = "usage: keygen [key file]"
+
+// runKeygen выполняет команду keygen: создаёт новый ключ подписи токенов. Без аргументов ключ выводится
+// в формате kid:секрет для TODO_JWT_KEYS. С путём к файлу ключ дописывается в конец файла (файл создаётся
+// с правами 0600): там он только проверяет токены, подписывать ими начнёт экземпляр, у которого этот ключ первый.
+func runKeygen(args []string) error {
+	if len(args) > 1 {
+		return errors.New(keygenUsage)
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fmt.Println(key)
+		return nil
+	}
+
+	file := args[0]
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	line := key.String() + "\n"
+	if len(data) > 0 {
+		// не дописываем ключ в файл, который сервер всё равно не сможет прочитать
+		if _, err := auth.ParseKeySet(string(data)); err != nil && !errors.Is(err, auth.ErrNoKeys) {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if data[len(data)-1] != '\n' {
+			line = "\n" + line
+		}
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("key %s added to %s\n", key.ID, file)
+	return nil
+}
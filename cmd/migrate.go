@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate выполняет команду migrate: up применяет новые миграции, down откатывает последние
+// (по умолчанию одну), status выводит список миграций с отметкой о применении.
+func runMigrate(dsn string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	conn, err := db.Open(dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return fmt.Errorf(migrateUsage)
+		}
+		err = db.MigrateUp(conn)
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps '%s'", args[1])
+			}
+		} else if len(args) > 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+		err = db.MigrateDown(conn, steps)
+	case "status":
+		err = printMigrationsStatus(conn)
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := db.CurrentVersion(conn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: schema version %d\n", conn.Dialect, version)
+	return nil
+}
+
+// printMigrationsStatus выводит миграции и время их применения
+func printMigrationsStatus(conn *db.DB) error {
+	migrations, err := db.MigrationsStatus(conn)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		appliedAt := m.AppliedAt
+		if appliedAt == "" {
+			appliedAt = "pending"
+		}
+		fmt.Printf("%04d_%-24s %s\n", m.Version, m.Name, appliedAt)
+	}
+	return nil
+}
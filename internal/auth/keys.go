@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LegacySecretKey — секретный ключ, который раньше был зашит в приложение. Токены им больше не подписываются,
+// он нужен только для проверки хэшей паролей старого формата (LegacyHash).
+const LegacySecretKey = "my_very_secret_key"
+
+// MinKeySize — минимальная длина секрета ключа подписи в байтах
+const MinKeySize = 32
+
+var (
+	// ErrNoKeys — ключи подписи не заданы ни в переменной окружения, ни в файле
+	ErrNoKeys = errors.New("no signing keys configured")
+
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+// SigningKey — секрет для подписи токенов HMAC с идентификатором, который пишется в заголовок kid токена
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// String возвращает ключ в формате файла ключей: kid:секрет в base64url
+func (k SigningKey) String() string {
+	return k.ID + ":" + base64.RawURLEncoding.EncodeToString(k.Secret)
+}
+
+// KeySet — действующие ключи подписи. Первый ключ подписывает новые токены, остальные только проверяют
+// выданные ранее, пока идёт ротация.
+type KeySet struct {
+	keys []SigningKey
+}
+
+// NewKeySet создаёт набор ключей; ключей должен быть хотя бы один, идентификаторы не повторяются
+func NewKeySet(keys ...SigningKey) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("invalid key id '%s'", key.ID)
+		}
+		if len(key.Secret) < MinKeySize {
+			return nil, fmt.Errorf("key '%s' is shorter than %d bytes", key.ID, MinKeySize)
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate key id '%s'", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	return &KeySet{keys: keys}, nil
+}
+
+// ParseKeySet разбирает ключи в формате kid:секрет (секрет в base64url), по одному на строку или через запятую.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func ParseKeySet(text string) (*KeySet, error) {
+	var keys []SigningKey
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, secret, ok := strings.Cut(line, ":")
+		if !ok {
+			// сам ключ в сообщение не пишем: это может быть секрет без идентификатора
+			return nil, errors.New("invalid key: expected kid:secret")
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(secret, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret of key '%s': %w", id, err)
+		}
+		keys = append(keys, SigningKey{ID: strings.TrimSpace(id), Secret: raw})
+	}
+	return NewKeySet(keys...)
+}
+
+// LoadKeySet загружает ключи из значения переменной окружения или из файла ключей.
+// Задать нужно что-то одно; если не задано ничего, возвращается ErrNoKeys.
+func LoadKeySet(value string, file string) (*KeySet, error) {
+	switch {
+	case value != "" && file != "":
+		return nil, errors.New("signing keys are set both in the environment and in a key file")
+	case value != "":
+		return ParseKeySet(value)
+	case file != "":
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+			log.Printf("warning: key file %s is accessible by other users, use chmod 600", file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := ParseKeySet(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return keys, nil
+	}
+	return nil, ErrNoKeys
+}
+
+// GenerateKey создаёт случайный ключ; идентификатор содержит дату создания, чтобы ключи было легко различать
+func GenerateKey() (SigningKey, error) {
+	secret := make([]byte, MinKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return SigningKey{}, err
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return SigningKey{}, err
+	}
+	id := time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(suffix)
+	return SigningKey{ID: id, Secret: secret}, nil
+}
+
+// Signing возвращает ключ для подписи новых токенов
+func (s *KeySet) Signing() SigningKey {
+	return s.keys[0]
+}
+
+// Key ищет ключ по идентификатору из заголовка kid
+func (s *KeySet) Key(id string) (SigningKey, bool) {
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
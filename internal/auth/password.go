@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"regexp"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher хэширует пароли для хранения в БД и проверяет пароль по сохранённому хэшу
+type PasswordHasher interface {
+	// Hash возвращает хэш пароля с солью
+	Hash(password string) (string, error)
+	// Verify проверяет пароль. rehash означает, что пароль верный, но хэш устарел
+	// (другой алгоритм или параметры) и его стоит пересчитать через Hash.
+	Verify(password, hash string) (ok bool, rehash bool)
+}
+
+// DefaultBcryptCost — стоимость bcrypt для новых хэшей
+const DefaultBcryptCost = 12
+
+// legacyHashPattern — формат хэшей до перехода на bcrypt: hex SHA-256 от пароля и секретного ключа
+var legacyHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// BcryptHasher хэширует пароли bcrypt и принимает хэши старого формата (LegacyHash),
+// требуя их пересчёта после успешной проверки
+type BcryptHasher struct {
+	Cost         int
+	LegacySecret string // секретный ключ, с которым считались старые хэши
+}
+
+var _ PasswordHasher = BcryptHasher{}
+
+// NewBcryptHasher создаёт хэшер со стоимостью DefaultBcryptCost
+func NewBcryptHasher(legacySecret string) BcryptHasher {
+	return BcryptHasher{Cost: DefaultBcryptCost, LegacySecret: legacySecret}
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > 72 {
+		// bcrypt учитывает только первые 72 байта
+		return "", errors.New("password is too long")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Verify(password, hash string) (bool, bool) {
+	if legacyHashPattern.MatchString(hash) {
+		legacy := LegacyHash(password, h.LegacySecret)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(hash)) == 1, true
+	}
+
+	// CompareHashAndPassword сравнивает хэши за постоянное время
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, err != nil || cost < h.Cost
+}
+
+// LegacyHash — несолёный SHA-256 от пароля и секретного ключа, которым хэшировались пароли
+// и подписывались токены до перехода на bcrypt
+func LegacyHash(password, secret string) string {
+	sum := sha256.Sum256(append([]byte(password), secret...))
+	return hex.EncodeToString(sum[:])
+}
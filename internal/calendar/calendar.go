@@ -0,0 +1,209 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dateTemplate = "20060102"
+
+// Holiday — нерабочий день календаря
+type Holiday struct {
+	Date string `json:"date"`           // дата в формате 20060102
+	Name string `json:"name,omitempty"` // название праздника
+}
+
+// Calendar хранит праздничные дни. Суббота и воскресенье всегда считаются выходными.
+// Календарь загружается из файла JSON или ICS при старте и может меняться через API.
+type Calendar struct {
+	mu       sync.RWMutex
+	path     string
+	holidays map[string]Holiday
+}
+
+// NewCalendar создаёт пустой календарь без праздников
+func NewCalendar() *Calendar {
+	return &Calendar{holidays: map[string]Holiday{}}
+}
+
+// Load загружает календарь из файла. Формат определяется по расширению: .ics или .json.
+// Если файла нет, возвращается пустой календарь, который будет сохранён в этот файл при изменении.
+func Load(path string) (*Calendar, error) {
+	c := NewCalendar()
+	c.path = path
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("Holidays file %s doesn't exist.", path)
+			return c, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var holidays []Holiday
+	if isICS(path) {
+		holidays, err = parseICS(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&holidays)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in reading holidays file %s: %w", path, err)
+	}
+
+	for _, h := range holidays {
+		if _, err := time.Parse(dateTemplate, h.Date); err != nil {
+			return nil, fmt.Errorf("invalid holiday date '%s' in %s", h.Date, path)
+		}
+		c.holidays[h.Date] = h
+	}
+	log.Printf("Loaded %d holidays from %s", len(c.holidays), path)
+	return c, nil
+}
+
+// IsWorkingDay определяет, является ли дата рабочим днём
+func (c *Calendar) IsWorkingDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.holidays[t.Format(dateTemplate)]
+	return !ok
+}
+
+// Holidays возвращает праздники, отсортированные по дате
+func (c *Calendar) Holidays() []Holiday {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]Holiday, 0, len(c.holidays))
+	for _, h := range c.holidays {
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result
+}
+
+// AddHoliday добавляет праздник и сохраняет календарь
+func (c *Calendar) AddHoliday(h Holiday) error {
+	if _, err := time.Parse(dateTemplate, h.Date); err != nil {
+		return fmt.Errorf("invalid holiday date '%s'", h.Date)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.holidays[h.Date] = h
+	return c.save()
+}
+
+// RemoveHoliday удаляет праздник и сохраняет календарь
+func (c *Calendar) RemoveHoliday(date string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.holidays, date)
+	return c.save()
+}
+
+// save записывает календарь в файл JSON. Календари из ICS меняются только в памяти,
+// чтобы не перезаписывать исходный экспорт из календаря.
+func (c *Calendar) save() error {
+	if c.path == "" || isICS(c.path) {
+		return nil
+	}
+
+	holidays := make([]Holiday, 0, len(c.holidays))
+	for _, h := range c.holidays {
+		holidays = append(holidays, h)
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+
+	data, err := json.MarshalIndent(holidays, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+func isICS(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ics")
+}
+
+// parseICS читает события VEVENT из файла iCalendar.
+// Каждое событие на весь день (DTSTART;VALUE=DATE) даёт праздник на каждый день до DTEND (не включая).
+func parseICS(f *os.File) ([]Holiday, error) {
+	var (
+		holidays []Holiday
+		inEvent  bool
+		start    string
+		end      string
+		name     string
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		// у ключа могут быть параметры: DTSTART;VALUE=DATE
+		key, _, _ = strings.Cut(key, ";")
+
+		switch {
+		case key == "BEGIN" && value == "VEVENT":
+			inEvent, start, end, name = true, "", "", ""
+		case key == "END" && value == "VEVENT":
+			inEvent = false
+			days, err := expandICSEvent(start, end, name)
+			if err != nil {
+				return nil, err
+			}
+			holidays = append(holidays, days...)
+		case inEvent && key == "DTSTART":
+			start = value
+		case inEvent && key == "DTEND":
+			end = value
+		case inEvent && key == "SUMMARY":
+			name = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// expandICSEvent превращает событие iCalendar в список праздничных дней
+func expandICSEvent(start, end, name string) ([]Holiday, error) {
+	if len(start) < len(dateTemplate) {
+		return nil, fmt.Errorf("invalid DTSTART '%s'", start)
+	}
+	from, err := time.Parse(dateTemplate, start[:len(dateTemplate)])
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTSTART '%s'", start)
+	}
+
+	to := from.AddDate(0, 0, 1)
+	if len(end) >= len(dateTemplate) {
+		if t, err := time.Parse(dateTemplate, end[:len(dateTemplate)]); err == nil && t.After(from) {
+			to = t
+		}
+	}
+
+	var days []Holiday
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, Holiday{Date: d.Format(dateTemplate), Name: name})
+	}
+	return days, nil
+}
@@ -2,30 +2,67 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
 )
 
 const (
-	defaultPassword = "123456"
-	defaultPort     = "7540"
+	defaultPassword   = "123456"
+	defaultPort       = "7540"
+	defaultTrashTTL   = 30 * 24 * time.Hour
+	defaultTokenTTL   = time.Hour
+	defaultRefreshTTL = 30 * 24 * time.Hour
 )
 
 type Config struct {
-	AppPassword         string
-	EncryptionSecretKey string // секретный ключ шифрования
-	ApiPort             string
+	AppPassword  string
+	SigningKeys  *auth.KeySet // ключи подписи токенов
+	ApiPort      string
+	HolidaysFile string        // путь к календарю праздников (JSON или ICS), может быть пустым
+	TrashTTL     time.Duration // сколько задачи хранятся в корзине до окончательного удаления
+	TokenTTL     time.Duration // срок действия токена доступа
+	RefreshTTL   time.Duration // срок действия токена обновления
 }
 
 // NewConfig конструктор объекта конфигурации приложения
-func NewConfig(appPass string, encKey string, apiPort string) (*Config, error) {
+// Сроки trashTTL, tokenTTL и refreshTTL задаются в формате time.ParseDuration, например 720h;
+// по умолчанию корзина хранит задачи 30 дней, токен доступа действует час, токен обновления — 30 дней.
+func NewConfig(appPass string, keys *auth.KeySet, apiPort string, holidaysFile string,
+	trashTTL string, tokenTTL string, refreshTTL string) (*Config, error) {
 	if appPass == "" {
 		appPass = defaultPassword
 	}
-	if appPass == "" || encKey == "" {
+	if appPass == "" || keys == nil {
 		return nil, fmt.Errorf("invalid config")
 	}
 
 	if apiPort == "" {
 		apiPort = defaultPort
 	}
-	return &Config{AppPassword: appPass, EncryptionSecretKey: encKey, ApiPort: apiPort}, nil
+
+	cfg := &Config{AppPassword: appPass, SigningKeys: keys, ApiPort: apiPort, HolidaysFile: holidaysFile}
+	var err error
+	if cfg.TrashTTL, err = parseDuration("trash TTL", trashTTL, defaultTrashTTL); err != nil {
+		return nil, err
+	}
+	if cfg.TokenTTL, err = parseDuration("token TTL", tokenTTL, defaultTokenTTL); err != nil {
+		return nil, err
+	}
+	if cfg.RefreshTTL, err = parseDuration("refresh token TTL", refreshTTL, defaultRefreshTTL); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseDuration разбирает положительный срок; пустая строка — значение по умолчанию
+func parseDuration(name string, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s '%s'", name, value)
+	}
+	return d, nil
 }
@@ -1,11 +1,20 @@
 package dateutil
 
 import (
+	"errors"
 	"time"
 
 	"github.com/wisdomdevil/go_final_project/internal/parser"
 )
 
+// ErrNoNextDate сообщает, что правило повторения закончилось (условие until)
+var ErrNoNextDate = parser.ErrNoNextDate
+
+const (
+	DateTemplate      = "20060102"
+	TimestampTemplate = "20060102T1504" // дата со временем для правил h, min и t
+)
+
 // NextDate calculates the next task date using the specified repeat rule and dates (now and date)
 // NextDate разбирает правило повтора и применяет его к переданным датам
 // и возвращает следующую дату в формате строки
@@ -13,21 +22,154 @@ import (
 // now — время от которого ищется ближайшая дата;
 // date — исходное время в формате 20060102, от которого начинается отсчёт повторений;
 // repeat — правило повторения в одном из форматов:
-// d <число> - задача переносится на указанное число дней;
+// d <число> [bd] - задача переносится на указанное число дней (bd — рабочих дней);
 // y - задача выполняется ежегодно;
 // w <через запятую от 1 до 7> - задача назначается в указанные дни недели,
 // где 1 — понедельник, 7 — воскресенье;
 // m <через запятую от 1 до 31,-1,-2> [через запятую от 1 до 12] -
-// задача назначается в указанные дни месяца.
-func NextDate(now time.Time, date time.Time, repeat string) (string, error) {
+// задача назначается в указанные дни месяца;
+// h <число>, min <число> - задача повторяется каждые указанное число часов или минут;
+// t <через запятую время 15:04> - задача повторяется каждый день в указанное время.
+// Для правил h, min и t now и date передаются со временем, а результат возвращается в формате 20060102T1504;
+// m <через запятую [-]номер+день недели> [через запятую от 1 до 12] -
+// задача назначается на n-й (или n-й с конца) день недели месяца, например m 2tue или m -1fri.
+// К правилам w, m и y можно добавить интервал /<число>: w 1 /2 — каждый второй понедельник,
+// m 15 /3 — 15-е число раз в три месяца, y /2 — раз в два года. Отсчёт ведётся от date.
+// Модификатор shift для правил m и y переносит выпавшую на выходной дату на ближайший рабочий день.
+// В конце правила можно указать условие окончания: until <20060102> или count <число>.
+// Вместо коротких правил можно передать правило iCalendar RRULE, например FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2.
+// Если следующей даты нет, возвращается ErrNoNextDate.
+// skip — даты-исключения в формате 20060102, которые пропускаются при поиске.
+func NextDate(now time.Time, date time.Time, repeat string, skip ...string) (string, error) {
+	next, _, err := NextDateWithBase(now, date, repeat, skip...)
+	return next, err
+}
+
+// NextDateWithBase вычисляет следующую дату как NextDate и возвращает также дату по правилу до переноса
+// на рабочий день (модификатор shift). Если дата не переносилась, base совпадает с next.
+// Для правил с shift в date передаётся дата по правилу, а не перенесённая дата задачи.
+func NextDateWithBase(now time.Time, date time.Time, repeat string, skip ...string) (next string, base string, err error) {
 	pr, err := parser.ParseRepeat(now, date, repeat)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	if len(skip) > 0 {
+		pr, err = parser.NewSkipRepeat(pr, skip)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	d, b, err := parser.NextDateWithBase(pr, now, date)
+	if err != nil {
+		return "", "", err
+	}
+	return formatNext(d, repeat), formatNext(b, repeat), nil
+}
+
+// RepeatCount возвращает число повторений из условия count правила или 0, если ограничения нет
+func RepeatCount(repeat string) (int, error) {
+	return parser.RepeatCount(repeat)
+}
+
+// ValidateRepeat проверяет, что правило повторения корректно разбирается
+func ValidateRepeat(repeat string) error {
+	now := time.Now()
+	_, err := parser.ParseRepeat(now, now, repeat)
+	return err
+}
+
+// NextDates возвращает до count следующих дат по правилу repeat, начиная после now.
+// Если to не нулевое, даты позже to не возвращаются. Поиск прекращается, когда правило заканчивается
+// (until или число повторений count в самом правиле).
+func NextDates(now time.Time, date time.Time, repeat string, count int, to time.Time, skip ...string) ([]string, error) {
+	pr, err := parser.ParseRepeat(now, date, repeat)
+	if err != nil {
+		return nil, err
+	}
+	if len(skip) > 0 {
+		pr, err = parser.NewSkipRepeat(pr, skip)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	d, err := pr.GetNextDate(now, date)
+	ruleCount, err := parser.RepeatCount(repeat)
+	if err != nil {
+		return nil, err
+	}
+	if ruleCount > 0 && ruleCount < count {
+		count = ruleCount
+	}
+
+	result := []string{}
+	current := now
+	for len(result) < count {
+		d, err := pr.GetNextDate(current, date)
+		if errors.Is(err, ErrNoNextDate) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !to.IsZero() && d.After(to) {
+			break
+		}
+		result = append(result, formatNext(d, repeat))
+		current = d
+	}
+	return result, nil
+}
+
+// DescribeRepeat возвращает описание правила повторения на языке lang (parser.LangRu или parser.LangEn).
+// remaining — остаток повторений задачи для правила с условием count, 0 — описать только общее число.
+func DescribeRepeat(repeat string, lang string, remaining int, skip ...string) (string, error) {
+	now := time.Now()
+	pr, err := parser.ParseRepeat(now, now, repeat)
 	if err != nil {
 		return "", err
 	}
-	return d.Format("20060102"), nil
+	parser.SetRemaining(pr, remaining)
+	if len(skip) > 0 {
+		pr, err = parser.NewSkipRepeat(pr, skip)
+		if err != nil {
+			return "", err
+		}
+	}
+	return pr.Describe(lang), nil
+}
+
+// Today возвращает сегодняшнюю дату в часовом поясе loc.
+// Дата возвращается в UTC без времени, как и даты, разобранные из формата 20060102.
+func Today(loc *time.Location) time.Time {
+	n := time.Now().In(loc)
+	return parser.Date(n.Year(), int(n.Month()), n.Day())
+}
+
+// IsSubDayRepeat определяет, что правило повторяется чаще раза в день и работает со временем
+func IsSubDayRepeat(repeat string) bool {
+	return parser.IsSubDayRule(repeat)
+}
+
+// Now возвращает текущие дату и время (с точностью до минуты) в часовом поясе loc.
+// Как и Today, значение хранится в UTC: часы и минуты совпадают с местными.
+func Now(loc *time.Location) time.Time {
+	n := time.Now().In(loc)
+	return time.Date(n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), 0, 0, time.UTC)
+}
+
+// ParseDateOrTimestamp разбирает дату в формате 20060102 или дату со временем 20060102T1504
+func ParseDateOrTimestamp(value string) (time.Time, error) {
+	if len(value) == len(TimestampTemplate) {
+		return time.Parse(TimestampTemplate, value)
+	}
+	return time.Parse(DateTemplate, value)
+}
+
+// formatNext форматирует следующую дату: для правил h, min и t — вместе со временем
+func formatNext(d time.Time, repeat string) string {
+	if IsSubDayRepeat(repeat) {
+		return d.Format(TimestampTemplate)
+	}
+	return d.Format(DateTemplate)
 }
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Dialect — диалект SQL хранилища задач
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// DialectFromDSN определяет диалект по строке подключения: postgres://… и postgresql://… — PostgreSQL,
+// всё остальное считается путём к файлу SQLite
+func DialectFromDSN(dsn string) Dialect {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
+}
+
+// Rebind приводит запрос с именованными параметрами (:name, sql.Named) к виду, который понимает драйвер.
+// SQLite поддерживает их сам, для PostgreSQL параметры заменяются на $1, $2… в порядке первого появления.
+func (d Dialect) Rebind(query string, args []any) (string, []any) {
+	if d != Postgres || len(args) == 0 {
+		return query, args
+	}
+
+	named := map[string]any{}
+	for _, arg := range args {
+		if na, ok := arg.(sql.NamedArg); ok {
+			named[na.Name] = na.Value
+		}
+	}
+	if len(named) == 0 {
+		return query, args
+	}
+
+	var (
+		b         strings.Builder
+		positions = map[string]int{}
+		result    []any
+		inString  bool
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			inString = !inString
+		}
+		if inString || c != ':' || i+1 >= len(query) || !isIdentStart(query[i+1]) || (i > 0 && query[i-1] == ':') {
+			b.WriteByte(c)
+			continue
+		}
+
+		j := i + 1
+		for j < len(query) && isIdentChar(query[j]) {
+			j++
+		}
+		name := query[i+1 : j]
+		pos, ok := positions[name]
+		if !ok {
+			result = append(result, named[name])
+			pos = len(result)
+			positions[name] = pos
+		}
+		fmt.Fprintf(&b, "$%d", pos)
+		i = j - 1
+	}
+	return b.String(), result
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// --------------------------------------------------------
+
+// DB — подключение к БД с известным диалектом. Exec, Query и QueryRow принимают именованные
+// параметры :name в любом диалекте.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open открывает БД по строке подключения: URL PostgreSQL или путь к файлу SQLite
+func Open(dsn string) (*DB, error) {
+	dialect := DialectFromDSN(dsn)
+	conn, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &DB{DB: conn, Dialect: dialect}, nil
+}
+
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	query, args = db.Dialect.Rebind(query, args)
+	return db.DB.Exec(query, args...)
+}
+
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	query, args = db.Dialect.Rebind(query, args)
+	return db.DB.Query(query, args...)
+}
+
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	query, args = db.Dialect.Rebind(query, args)
+	return db.DB.QueryRow(query, args...)
+}
+
+// InsertReturningID выполняет INSERT и возвращает id новой строки
+func (db *DB) InsertReturningID(query string, args ...any) (int64, error) {
+	return insertReturningID(db, db.Dialect, query, args...)
+}
+
+// insertReturningID выполняет INSERT через q. В PostgreSQL нет LastInsertId, поэтому к запросу добавляется RETURNING id.
+func insertReturningID(q Querier, dialect Dialect, query string, args ...any) (int64, error) {
+	if dialect == Postgres {
+		var id int64
+		err := q.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	res, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Begin начинает транзакцию с тем же разбором именованных параметров
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: db.Dialect}, nil
+}
+
+// Querier — общие методы DB и Tx: код, который выполняет запросы через Querier,
+// работает одинаково и вне транзакции, и внутри неё
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	InsertReturningID(query string, args ...any) (int64, error)
+}
+
+var (
+	_ Querier = (*DB)(nil)
+	_ Querier = (*Tx)(nil)
+)
+
+// Tx — транзакция DB
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	query, args = tx.dialect.Rebind(query, args)
+	return tx.Tx.Exec(query, args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	query, args = tx.dialect.Rebind(query, args)
+	return tx.Tx.QueryRow(query, args...)
+}
+
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	query, args = tx.dialect.Rebind(query, args)
+	return tx.Tx.Query(query, args...)
+}
+
+// InsertReturningID выполняет INSERT в транзакции и возвращает id новой строки
+func (tx *Tx) InsertReturningID(query string, args ...any) (int64, error) {
+	return insertReturningID(tx, tx.dialect, query, args...)
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Миграции схемы лежат в migrations/<диалект>/ и встраиваются в бинарник.
+// Имя файла: <номер>_<название>.up.sql или <номер>_<название>.down.sql, например 0002_repeat_limits.up.sql.
+// Номера и названия миграций в разных диалектах совпадают.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration — одна версия схемы с SQL для перехода вверх и отката
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus — состояние миграции в БД
+type MigrationStatus struct {
+	Migration
+	AppliedAt string // момент применения в UTC (RFC 3339), пусто, если миграция не применена
+}
+
+// schemaVersionQuery создаёт таблицу применённых миграций
+const schemaVersionQuery = `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name    VARCHAR(128) NOT NULL,
+		applied_at VARCHAR(32) NOT NULL
+	);`
+
+// legacyVersion — версия схемы баз, созданных до появления миграций
+const legacyVersion = 1
+
+// loadMigrations читает встроенные миграции диалекта, упорядоченные по номеру
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/"+string(dialect)+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		name := path.Base(file)
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		num, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+
+		data, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, found := byVersion[version]
+		if !found {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up script", m.Version)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	for i, m := range result {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return result, nil
+}
+
+// prepareSchemaVersion создаёт таблицу schema_version. Если в БД уже есть таблица scheduler,
+// созданная до появления миграций, она считается схемой версии legacyVersion.
+func prepareSchemaVersion(db *DB) error {
+	exists, err := tableExists(db, "schema_version")
+	if err != nil || exists {
+		return err
+	}
+
+	legacy, err := tableExists(db, "scheduler")
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(schemaVersionQuery); err != nil {
+		return err
+	}
+	if legacy {
+		log.Printf("Existing database without schema_version, assuming version %d", legacyVersion)
+		_, err = db.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (:version, 'legacy', :applied_at)",
+			sql.Named("version", legacyVersion),
+			sql.Named("applied_at", time.Now().UTC().Format(time.RFC3339)))
+	}
+	return err
+}
+
+// CurrentVersion возвращает текущую версию схемы, 0 для пустой БД
+func CurrentVersion(db *DB) (int, error) {
+	if err := prepareSchemaVersion(db); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// MigrateUp применяет все ещё не применённые миграции. Каждая миграция выполняется в своей транзакции.
+func MigrateUp(db *DB) error {
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the application (%d)", current, len(migrations))
+	}
+
+	for _, m := range migrations[current:] {
+		err := inTx(db, func(tx *Tx) error {
+			if _, err := tx.Exec(m.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (:version, :name, :applied_at)",
+				sql.Named("version", m.Version),
+				sql.Named("name", m.Name),
+				sql.Named("applied_at", time.Now().UTC().Format(time.RFC3339)))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// MigrateDown откатывает steps последних применённых миграций
+func MigrateDown(db *DB, steps int) error {
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the application (%d)", current, len(migrations))
+	}
+
+	for ; steps > 0 && current > 0; steps-- {
+		m := migrations[current-1]
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s can't be rolled back", m.Version, m.Name)
+		}
+		err := inTx(db, func(tx *Tx) error {
+			if _, err := tx.Exec(m.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_version WHERE version = :version", sql.Named("version", m.Version))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rollback of migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("Rolled back migration %04d_%s", m.Version, m.Name)
+		current--
+	}
+	return nil
+}
+
+// MigrationsStatus возвращает все известные миграции с отметкой о применении
+func MigrationsStatus(db *DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSchemaVersion(db); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]string{}
+	rows, err := db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt string
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, MigrationStatus{Migration: m, AppliedAt: applied[m.Version]})
+	}
+	return result, nil
+}
+
+// tableExists проверяет, есть ли в БД таблица name
+func tableExists(db *DB, name string) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = :name"
+	if db.Dialect == Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = :name"
+	}
+	var count int
+	err := db.QueryRow(query, sql.Named("name", name)).Scan(&count)
+	return count > 0, err
+}
+
+// inTx выполняет f в транзакции и фиксирует её, если f не вернула ошибку
+func inTx(db *DB, f func(tx *Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
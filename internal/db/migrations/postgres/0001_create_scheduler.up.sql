@@ -0,0 +1,9 @@
+-- исходная таблица задач (в тестах scheduler)
+CREATE TABLE IF NOT EXISTS scheduler (
+	id SERIAL PRIMARY KEY,
+	date    VARCHAR(8) NOT NULL,
+	title   VARCHAR(128) NOT NULL,
+	comment VARCHAR(250),
+	repeat  VARCHAR(128)
+);
+CREATE INDEX IF NOT EXISTS scheduler_date ON scheduler(date);
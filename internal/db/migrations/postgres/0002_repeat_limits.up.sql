@@ -0,0 +1,3 @@
+-- остаток повторений для условия count и даты-исключения
+ALTER TABLE scheduler ADD COLUMN remaining INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scheduler ADD COLUMN skip VARCHAR(1024) NOT NULL DEFAULT '';
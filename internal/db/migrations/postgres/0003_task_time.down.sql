@@ -0,0 +1,3 @@
+ALTER TABLE scheduler DROP COLUMN anchor;
+ALTER TABLE scheduler DROP COLUMN timezone;
+ALTER TABLE scheduler DROP COLUMN time;
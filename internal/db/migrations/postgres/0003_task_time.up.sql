@@ -0,0 +1,4 @@
+-- время, часовой пояс и режим отсчёта следующей даты
+ALTER TABLE scheduler ADD COLUMN time VARCHAR(5) NOT NULL DEFAULT '';
+ALTER TABLE scheduler ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE scheduler ADD COLUMN anchor VARCHAR(16) NOT NULL DEFAULT 'schedule';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS task_completions_task_id;
+DROP TABLE IF EXISTS task_completions;
@@ -0,0 +1,5 @@
+-- задачи из корзины при откате удаляются окончательно
+DELETE FROM scheduler WHERE deleted_at != '';
+ALTER TABLE task_undo DROP COLUMN deleted_at;
+DROP INDEX IF EXISTS scheduler_deleted_at;
+ALTER TABLE scheduler DROP COLUMN deleted_at;
@@ -0,0 +1,4 @@
+-- корзина: удалённые задачи помечаются моментом удаления
+ALTER TABLE scheduler ADD COLUMN deleted_at VARCHAR(32) NOT NULL DEFAULT '';
+CREATE INDEX scheduler_deleted_at ON scheduler(deleted_at);
+ALTER TABLE task_undo ADD COLUMN deleted_at VARCHAR(32) NOT NULL DEFAULT '';
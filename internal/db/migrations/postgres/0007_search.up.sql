@@ -0,0 +1,3 @@
+-- полнотекстовый индекс задач по заголовку и комментарию
+CREATE INDEX scheduler_search ON scheduler
+    USING GIN (to_tsvector('simple', title || ' ' || COALESCE(comment, '')));
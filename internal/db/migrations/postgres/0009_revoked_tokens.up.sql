@@ -0,0 +1,6 @@
+-- отозванные токены (jti) до истечения их срока действия
+CREATE TABLE revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	expires_at VARCHAR(32) NOT NULL
+);
+CREATE INDEX revoked_tokens_expires_at ON revoked_tokens(expires_at);
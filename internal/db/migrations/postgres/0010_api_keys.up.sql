@@ -0,0 +1,12 @@
+-- личные API-ключи пользователей для скриптов; хранится только SHA-256 ключа
+CREATE TABLE api_keys (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	name VARCHAR(128) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	prefix VARCHAR(16) NOT NULL,
+	read_only INTEGER NOT NULL DEFAULT 0,
+	created_at VARCHAR(32) NOT NULL,
+	last_used_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+CREATE INDEX api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE task_undo DROP COLUMN rule_date;
+ALTER TABLE scheduler DROP COLUMN rule_date;
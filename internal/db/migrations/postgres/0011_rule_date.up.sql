@@ -0,0 +1,3 @@
+-- дата по правилу до переноса shift на рабочий день: от неё считается следующая дата, чтобы перенос не накапливался
+ALTER TABLE scheduler ADD COLUMN rule_date VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE task_undo ADD COLUMN rule_date VARCHAR(8) NOT NULL DEFAULT '';
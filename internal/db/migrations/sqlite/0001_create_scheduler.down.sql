@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS scheduler_date;
+DROP TABLE IF EXISTS scheduler;
@@ -0,0 +1,2 @@
+ALTER TABLE scheduler DROP COLUMN skip;
+ALTER TABLE scheduler DROP COLUMN remaining;
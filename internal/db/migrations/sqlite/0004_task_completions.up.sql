@@ -0,0 +1,10 @@
+-- история выполнений задач
+CREATE TABLE task_completions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id INTEGER NOT NULL,
+	date    VARCHAR(8) NOT NULL,
+	time    VARCHAR(5) NOT NULL DEFAULT '',
+	completed_at VARCHAR(32) NOT NULL,
+	note    VARCHAR(1024) NOT NULL DEFAULT ''
+);
+CREATE INDEX task_completions_task_id ON task_completions(task_id);
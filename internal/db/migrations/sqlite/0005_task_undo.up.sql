@@ -0,0 +1,16 @@
+-- снимки задач для отмены выполнения и удаления
+CREATE TABLE task_undo (
+	token   VARCHAR(64) PRIMARY KEY,
+	expires_at VARCHAR(32) NOT NULL,
+	last_completion_id INTEGER NOT NULL DEFAULT 0,
+	id      INTEGER NOT NULL,
+	date    VARCHAR(8) NOT NULL,
+	title   VARCHAR(128) NOT NULL,
+	comment VARCHAR(250),
+	repeat  VARCHAR(128),
+	remaining INTEGER NOT NULL DEFAULT 0,
+	skip    VARCHAR(1024) NOT NULL DEFAULT '',
+	time    VARCHAR(5) NOT NULL DEFAULT '',
+	timezone VARCHAR(64) NOT NULL DEFAULT '',
+	anchor  VARCHAR(16) NOT NULL DEFAULT 'schedule'
+);
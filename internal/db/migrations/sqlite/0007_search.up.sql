@@ -0,0 +1,18 @@
+-- полнотекстовый индекс задач по заголовку и комментарию, поддерживается триггерами
+CREATE VIRTUAL TABLE scheduler_fts USING fts5(
+    title, comment,
+    content = 'scheduler', content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+INSERT INTO scheduler_fts(scheduler_fts) VALUES ('rebuild');
+
+CREATE TRIGGER scheduler_fts_ai AFTER INSERT ON scheduler BEGIN
+    INSERT INTO scheduler_fts(rowid, title, comment) VALUES (new.id, new.title, new.comment);
+END;
+CREATE TRIGGER scheduler_fts_ad AFTER DELETE ON scheduler BEGIN
+    INSERT INTO scheduler_fts(scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+END;
+CREATE TRIGGER scheduler_fts_au AFTER UPDATE OF title, comment ON scheduler BEGIN
+    INSERT INTO scheduler_fts(scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+    INSERT INTO scheduler_fts(rowid, title, comment) VALUES (new.id, new.title, new.comment);
+END;
@@ -0,0 +1,5 @@
+ALTER TABLE task_undo DROP COLUMN user_id;
+ALTER TABLE task_completions DROP COLUMN user_id;
+DROP INDEX IF EXISTS scheduler_user_id;
+ALTER TABLE scheduler DROP COLUMN user_id;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,14 @@
+-- пользователи: задачи, история выполнений и снимки отмены принадлежат пользователю
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	login VARCHAR(64) NOT NULL UNIQUE,
+	password_hash VARCHAR(256) NOT NULL DEFAULT '',
+	is_admin INTEGER NOT NULL DEFAULT 0,
+	created_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+-- администратор владеет задачами, созданными до появления пользователей; пароль задаётся при запуске из TODO_PASSWORD
+INSERT INTO users (id, login, is_admin) VALUES (1, 'admin', 1);
+ALTER TABLE scheduler ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX scheduler_user_id ON scheduler(user_id, date);
+ALTER TABLE task_completions ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE task_undo ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
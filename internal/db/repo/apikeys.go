@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// APIKeyStore — хранилище личных API-ключей пользователей
+type APIKeyStore interface {
+	AddAPIKey(key models.APIKey) (int, error)
+	GetAPIKeys(userID int) ([]models.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (models.APIKey, error)
+	TouchAPIKey(id int, used time.Time) error
+	DeleteAPIKey(userID int, id int) error
+}
+
+// apiKeyColumns — столбцы таблицы api_keys в порядке, который ожидает scanAPIKey
+const apiKeyColumns = "id, user_id, name, key_hash, prefix, read_only, created_at, last_used_at"
+
+func scanAPIKey(row rowScanner, k *models.APIKey) error {
+	var readOnly int
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.KeyHash, &k.Prefix, &readOnly, &k.CreatedAt, &k.LastUsed); err != nil {
+		return err
+	}
+	k.ReadOnly = readOnly != 0
+	return nil
+}
+
+// AddAPIKey сохраняет новый ключ пользователя; дата создания проставляется здесь
+func (tr TasksRepository) AddAPIKey(k models.APIKey) (int, error) {
+	readOnly := 0
+	if k.ReadOnly {
+		readOnly = 1
+	}
+	id, err := tr.conn.InsertReturningID("INSERT INTO api_keys (user_id, name, key_hash, prefix, read_only, created_at) "+
+		"VALUES (:user_id, :name, :key_hash, :prefix, :read_only, :created_at)",
+		sql.Named("user_id", k.UserID),
+		sql.Named("name", k.Name),
+		sql.Named("key_hash", k.KeyHash),
+		sql.Named("prefix", k.Prefix),
+		sql.Named("read_only", readOnly),
+		sql.Named("created_at", time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetAPIKeys возвращает ключи пользователя в порядке создания
+func (tr TasksRepository) GetAPIKeys(userID int) ([]models.APIKey, error) {
+	rows, err := tr.conn.Query("SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = :user_id ORDER BY id",
+		sql.Named("user_id", userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		k := models.APIKey{}
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash возвращает ключ по хэшу или sql.ErrNoRows
+func (tr TasksRepository) GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	k := models.APIKey{}
+	row := tr.conn.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = :key_hash",
+		sql.Named("key_hash", keyHash))
+	if err := scanAPIKey(row, &k); err != nil {
+		return models.APIKey{}, err
+	}
+	return k, nil
+}
+
+// TouchAPIKey запоминает время последнего использования ключа
+func (tr TasksRepository) TouchAPIKey(id int, used time.Time) error {
+	_, err := tr.conn.Exec("UPDATE api_keys SET last_used_at = :last_used_at WHERE id = :id",
+		sql.Named("last_used_at", used.UTC().Format(time.RFC3339)),
+		sql.Named("id", id))
+	return err
+}
+
+// DeleteAPIKey отзывает ключ пользователя. Чужой или несуществующий ключ — sql.ErrNoRows.
+func (tr TasksRepository) DeleteAPIKey(userID int, id int) error {
+	res, err := tr.conn.Exec("DELETE FROM api_keys WHERE id = :id AND user_id = :user_id",
+		sql.Named("id", id),
+		sql.Named("user_id", userID))
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
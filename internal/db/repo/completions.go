@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// AddCompletion записывает в историю выполнение задачи с датой по расписанию и заметкой.
+// Запись принадлежит владельцу задачи, чтобы история оставалась доступна ему и после удаления задачи.
+func (tr TasksRepository) AddCompletion(t models.Task, note string) error {
+	_, err := tr.conn.Exec("INSERT INTO task_completions (task_id, date, time, completed_at, note, user_id) "+
+		"VALUES (:task_id, :date, :time, :completed_at, :note, :user_id)",
+		sql.Named("task_id", t.ID),
+		sql.Named("date", t.Date),
+		sql.Named("time", t.Time),
+		sql.Named("completed_at", time.Now().UTC().Format(time.RFC3339)),
+		sql.Named("note", note),
+		sql.Named("user_id", t.UserID))
+	return err
+}
+
+// GetCompletions возвращает историю выполнений задачи в порядке выполнения
+func (tr TasksRepository) GetCompletions(taskID int) ([]models.Completion, error) {
+	rows, err := tr.conn.Query("SELECT id, task_id, date, time, completed_at, note FROM task_completions "+
+		"WHERE task_id = :task_id AND (:user_id = 0 OR user_id = :user_id) ORDER BY id",
+		sql.Named("task_id", taskID),
+		tr.owner())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Completion{}
+	for rows.Next() {
+		c := models.Completion{}
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Date, &c.Time, &c.CompletedAt, &c.Note); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+	"github.com/wisdomdevil/go_final_project/internal/parser"
+)
+
+// ErrInvalidFilter — в строке поиска неверное значение фильтра
+var ErrInvalidFilter = errors.New("invalid search filter")
+
+// filterCondition — условие фильтра по столбцам задачи. В sql на месте %s подставляется имя параметра со значением value.
+type filterCondition struct {
+	sql   string
+	value string
+	match func(t models.Task) bool
+}
+
+// Filter — разобранная строка поиска. Все условия должны выполняться одновременно.
+//
+//	слово "фраза" нач*     — полнотекстовый поиск по заголовку и комментарию
+//	title:слово            — слово (фраза, начало слова) в заголовке
+//	repeat:w               — тип правила повторения: d, w, m, y, h, min, t, rrule или none
+//	before:20261231        — дата задачи раньше указанной, after: — позже (также в формате 02.01.2006)
+//	has:comment            — у задачи есть комментарий; также has:repeat, has:time, has:skip
+//	02.01.2006             — задачи на эту дату
+type Filter struct {
+	Text       []searchTerm
+	Title      []searchTerm
+	conditions []filterCondition
+}
+
+// ParseFilter разбирает строку поиска. Неизвестные ключи (например, в "18:00") считаются обычным текстом.
+func ParseFilter(search string) (*Filter, error) {
+	f := &Filter{}
+	for _, token := range splitSearchTokens(search) {
+		key, value, found := strings.Cut(token, ":")
+		if !found {
+			if date, err := time.Parse("02.01.2006", token); err == nil {
+				f.addCondition("scheduler.date = :%s", date.Format(timeTemplate), func(t models.Task) bool {
+					return t.Date == date.Format(timeTemplate)
+				})
+				continue
+			}
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "title":
+			if term, ok := parseSearchTerm(value); ok {
+				f.Title = append(f.Title, term)
+			}
+		case "repeat":
+			err = f.addRepeat(value)
+		case "before", "after":
+			err = f.addDateBound(strings.ToLower(key), value)
+		case "has":
+			err = f.addHas(value)
+		default:
+			if term, ok := parseSearchTerm(token); ok {
+				f.Text = append(f.Text, term)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *Filter) addCondition(sql, value string, match func(t models.Task) bool) {
+	f.conditions = append(f.conditions, filterCondition{sql: sql, value: value, match: match})
+}
+
+// addRepeat отбирает задачи по типу правила повторения — первому слову repeat
+func (f *Filter) addRepeat(value string) error {
+	kind := strings.ToLower(value)
+	switch kind {
+	case "none":
+		f.addCondition("scheduler.repeat = :%s", "", func(t models.Task) bool { return t.Repeat == "" })
+	case "rrule":
+		// правило RRULE записывается и с префиксом RRULE:, и в нижнем регистре, как его принимает parser.IsRRule
+		f.addCondition("(UPPER(scheduler.repeat) LIKE :%s OR UPPER(scheduler.repeat) LIKE 'RRULE:%%')", "FREQ=%",
+			func(t models.Task) bool { return parser.IsRRule(t.Repeat) })
+	case "d", "w", "m", "y", "h", "min", "t":
+		f.addCondition("scheduler.repeat || ' ' LIKE :%s", kind+" %", func(t models.Task) bool {
+			return strings.HasPrefix(t.Repeat+" ", kind+" ")
+		})
+	default:
+		return fmt.Errorf("%w: repeat:%s", ErrInvalidFilter, value)
+	}
+	return nil
+}
+
+// addDateBound отбирает задачи с датой строго раньше (before) или позже (after) указанной
+func (f *Filter) addDateBound(key, value string) error {
+	date, err := time.Parse(timeTemplate, value)
+	if err != nil {
+		date, err = time.Parse("02.01.2006", value)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s:%s", ErrInvalidFilter, key, value)
+	}
+	bound := date.Format(timeTemplate)
+
+	if key == "before" {
+		f.addCondition("scheduler.date < :%s", bound, func(t models.Task) bool { return t.Date < bound })
+	} else {
+		f.addCondition("scheduler.date > :%s", bound, func(t models.Task) bool { return t.Date > bound })
+	}
+	return nil
+}
+
+// addHas отбирает задачи с непустым полем
+func (f *Filter) addHas(value string) error {
+	fields := map[string]func(t models.Task) string{
+		"comment": func(t models.Task) string { return t.Comment },
+		"repeat":  func(t models.Task) string { return t.Repeat },
+		"time":    func(t models.Task) string { return t.Time },
+		"skip":    func(t models.Task) string { return t.Skip },
+	}
+	field := strings.ToLower(value)
+	get, ok := fields[field]
+	if !ok {
+		return fmt.Errorf("%w: has:%s", ErrInvalidFilter, value)
+	}
+	f.addCondition("scheduler."+field+" != :%s", "", func(t models.Task) bool { return get(t) != "" })
+	return nil
+}
+
+// empty сообщает, что в строке поиска не оказалось ни одного условия
+func (f *Filter) empty() bool {
+	return len(f.Text) == 0 && len(f.Title) == 0 && len(f.conditions) == 0
+}
+
+// GetQueryData собирает условие WHERE с параметрами search, title и filterN вместо значений из строки поиска
+func (f *Filter) GetQueryData(dialect db.Dialect) *QueryData {
+	if f.empty() {
+		return &QueryData{Condition: "1 = 0"}
+	}
+
+	qd := &QueryData{}
+	var conditions []string
+	if len(f.Text) > 0 || len(f.Title) > 0 {
+		conditions = append(conditions, f.fullTextQuery(dialect, qd))
+	}
+	for i, c := range f.conditions {
+		name := fmt.Sprintf("filter%d", i)
+		conditions = append(conditions, fmt.Sprintf(c.sql, name))
+		qd.Args = append(qd.Args, sql.Named(name, c.value))
+	}
+	qd.Condition = strings.Join(conditions, " AND ")
+	return qd
+}
+
+// fullTextQuery заполняет в qd присоединение индекса, порядок по релевантности и фрагмент текста,
+// возвращает условие полнотекстового поиска
+func (f *Filter) fullTextQuery(dialect db.Dialect, qd *QueryData) string {
+	if dialect == db.Postgres {
+		var conditions []string
+		if len(f.Text) > 0 {
+			conditions = append(conditions, pgSearchVector+" @@ to_tsquery('simple', :search)")
+			qd.Args = append(qd.Args, sql.Named("search", tsQuery(f.Text)))
+		}
+		if len(f.Title) > 0 {
+			conditions = append(conditions, "to_tsvector('simple', scheduler.title) @@ to_tsquery('simple', :title)")
+			qd.Args = append(qd.Args, sql.Named("title", tsQuery(f.Title)))
+		}
+		// релевантность и фрагмент считаются по всем словам поиска
+		qd.Args = append(qd.Args, sql.Named("rank", tsQuery(append(append([]searchTerm{}, f.Text...), f.Title...))))
+		qd.OrderBy = "ts_rank(" + pgSearchVector + ", to_tsquery('simple', :rank)) DESC, scheduler.date, scheduler.time"
+		qd.Snippet = "ts_headline('simple', scheduler.title || ' ' || COALESCE(scheduler.comment, ''), " +
+			"to_tsquery('simple', :rank), 'StartSel=" + snippetStart + ", StopSel=" + snippetStop + ", MaxWords=12, MinWords=3')"
+		return strings.Join(conditions, " AND ")
+	}
+
+	match := strings.TrimSpace(ftsQuery(f.Text, "") + " " + ftsQuery(f.Title, "title"))
+	qd.Args = append(qd.Args, sql.Named("search", match))
+	qd.Join = "JOIN scheduler_fts ON scheduler_fts.rowid = scheduler.id"
+	qd.OrderBy = "bm25(scheduler_fts), scheduler.date, scheduler.time"
+	qd.Snippet = "snippet(scheduler_fts, -1, '" + snippetStart + "', '" + snippetStop + "', '…', 12)"
+	return "scheduler_fts MATCH :search"
+}
+
+func (f *Filter) Matches(t models.Task) bool {
+	if f.empty() {
+		return false
+	}
+	title, comment := searchWords(t.Title), searchWords(t.Comment)
+	for _, term := range f.Text {
+		if !term.matches(title) && !term.matches(comment) {
+			return false
+		}
+	}
+	for _, term := range f.Title {
+		if !term.matches(title) {
+			return false
+		}
+	}
+	for _, c := range f.conditions {
+		if !c.match(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/dateutil"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// Логика выполнения задачи и дат-исключений общая для всех реализаций TaskStore:
+// хранилище только читает и записывает задачи.
+
+// postTaskDone moves task according the repeat rule.
+// Выполнение записывается в историю (task_completions) с датой по расписанию и заметкой note.
+func postTaskDone(store TaskStore, id int, note string) (*models.Task, error) {
+	t, err := store.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := completeTask(store, t)
+	if err != nil {
+		return nil, err
+	}
+	err = store.AddCompletion(t, note)
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// completeTask переносит задачу на следующую дату по правилу или удаляет её, если повторений больше нет.
+// Завершённая задача удаляется сразу, минуя корзину: запись о ней остаётся в истории выполнений.
+func completeTask(store TaskStore, t models.Task) (*models.Task, error) {
+	id, err := strconv.Atoi(t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	dt, err := t.RuleMoment()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Repeat == "" {
+		fmt.Println("Repeat is null")
+		err = store.PurgeTask(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// далее else
+	fmt.Println("Repeat is not null")
+
+	// для правила с условием count уменьшаем остаток, на последнем повторении задача завершается
+	count, err := dateutil.RepeatCount(t.Repeat)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		if t.Remaining <= 0 || t.Remaining > count {
+			t.Remaining = count
+		}
+		t.Remaining--
+		if t.Remaining == 0 {
+			return nil, store.PurgeTask(id)
+		}
+	}
+
+	// следующая дата считается от сегодняшней даты (для правил h, min, t — от текущего момента)
+	// в часовом поясе задачи, а для задач с отсчётом от выполнения — и сам отсчёт идёт от него
+	now := t.CurrentMoment()
+	if t.AnchoredToCompletion() {
+		dt = now
+	}
+	nextDate, base, err := dateutil.NextDateWithBase(now, dt, t.Repeat, t.SkipDates()...)
+	if errors.Is(err, dateutil.ErrNoNextDate) {
+		// правило закончилось (until), завершаем задачу как неповторяющуюся
+		return nil, store.PurgeTask(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = t.SetNextDateWithBase(nextDate, base)
+	if err != nil {
+		return nil, err
+	}
+	err = store.UpdateTaskDate(t, t.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// addTaskSkip добавляет задаче дату-исключение.
+// Если исключается текущая дата задачи, задача переносится на следующую дату по правилу.
+func addTaskSkip(store TaskStore, id int, date string) (models.Task, error) {
+	t, err := store.GetTask(id)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	err = t.AddSkipDate(date)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	if t.Date == date {
+		dt, err := time.Parse(timeTemplate, t.Date)
+		if err != nil {
+			return models.Task{}, err
+		}
+		ruleDate, err := t.RuleMoment()
+		if err != nil {
+			return models.Task{}, err
+		}
+		nextDate, base, err := dateutil.NextDateWithBase(dt, ruleDate, t.Repeat, t.SkipDates()...)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if err := t.SetNextDateWithBase(nextDate, base); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	err = store.UpdateTaskIn(t)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return t, nil
+}
+
+// removeTaskSkip удаляет у задачи дату-исключение
+func removeTaskSkip(store TaskStore, id int, date string) (models.Task, error) {
+	t, err := store.GetTask(id)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	t.RemoveSkipDate(date)
+
+	err = store.UpdateTaskIn(t)
+	if err != nil {
+		return models.Task{}, err
+	}
+	return t, nil
+}
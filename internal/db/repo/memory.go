@@ -0,0 +1,512 @@
+package repo
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// MemoryStore — хранилище задач в памяти для тестов хэндлеров и быстрых юнит-тестов.
+// Ведёт себя так же, как TasksRepository: отсутствующая задача — sql.ErrNoRows,
+// удалённые задачи лежат в корзине, выдача ограничена limitConst задачами.
+// Хранилища пользователей из ForUser работают с общими данными и видят только задачи своего пользователя.
+type MemoryStore struct {
+	*memoryData
+	userID int
+	locked bool // мьютекс уже захвачен в inTx, методы не блокируют его повторно
+}
+
+// memoryData — данные хранилища в памяти, общие для всех пользователей
+type memoryData struct {
+	mu               sync.Mutex
+	lastID           int
+	lastCompletionID int
+	lastUserID       int
+	lastAPIKeyID     int
+	tasks            map[int]models.Task
+	completions      []models.Completion
+	undo             map[string]undoSnapshot
+	users            map[int]models.User
+	revoked          map[string]time.Time
+	apiKeys          map[int]models.APIKey
+}
+
+// undoSnapshot — снимок задачи для отмены выполнения или удаления
+type undoSnapshot struct {
+	task             models.Task
+	lastCompletionID int
+	expires          time.Time
+}
+
+var _ TaskStore = (*MemoryStore)(nil)
+
+// NewMemoryStore создаёт пустое хранилище задач в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryData: &memoryData{
+		tasks:   map[int]models.Task{},
+		undo:    map[string]undoSnapshot{},
+		users:   map[int]models.User{},
+		revoked: map[string]time.Time{},
+		apiKeys: map[int]models.APIKey{},
+	}}
+}
+
+func (ms *MemoryStore) ForUser(userID int) TaskStore {
+	return &MemoryStore{memoryData: ms.memoryData, userID: userID}
+}
+
+// lock захватывает мьютекс данных и возвращает функцию, которая его отпускает.
+// Внутри inTx мьютекс уже захвачен, и lock ничего не делает.
+func (ms *MemoryStore) lock() (unlock func()) {
+	if ms.locked {
+		return func() {}
+	}
+	ms.mu.Lock()
+	return ms.mu.Unlock
+}
+
+// inTx выполняет f под одной блокировкой: другие запросы не увидят промежуточного состояния.
+// Методы хранилища в памяти не возвращают ошибок после изменения данных, поэтому откатывать нечего.
+func (ms *MemoryStore) inTx(f func(tx *MemoryStore) error) error {
+	defer ms.lock()()
+	return f(&MemoryStore{memoryData: ms.memoryData, userID: ms.userID, locked: true})
+}
+
+// owns сообщает, видит ли хранилище задачу, как условие ownTasks в TasksRepository
+func (ms *MemoryStore) owns(userID int) bool {
+	return ms.userID == 0 || userID == ms.userID
+}
+
+// task возвращает задачу пользователя хранилища, в том числе из корзины
+func (ms *MemoryStore) task(id int) (models.Task, bool) {
+	t, ok := ms.tasks[id]
+	return t, ok && ms.owns(t.UserID)
+}
+
+func (ms *MemoryStore) AddTask(t models.Task) (int, error) {
+	defer ms.lock()()
+
+	ms.lastID++
+	t.ID = strconv.Itoa(ms.lastID)
+	t.RepeatText = ""
+	t.DeletedAt = ""
+	t.UserID = ms.userID
+	ms.tasks[ms.lastID] = t
+	return ms.lastID, nil
+}
+
+func (ms *MemoryStore) GetTask(id int) (models.Task, error) {
+	defer ms.lock()()
+
+	t, ok := ms.task(id)
+	if !ok || t.DeletedAt != "" {
+		return models.Task{}, sql.ErrNoRows
+	}
+	return t, nil
+}
+
+func (ms *MemoryStore) GetAllTasks(page Page) ([]models.Task, string, error) {
+	if err := page.checkCursor(SortDate); err != nil {
+		return nil, "", err
+	}
+	earliestToday := time.Now().UTC().AddDate(0, 0, -1).Format(timeTemplate)
+
+	result := []models.Task{}
+	for _, t := range ms.page(page, func(t models.Task) bool { return t.DeletedAt == "" && t.Date >= earliestToday }) {
+		if !t.IsUpcoming() {
+			continue
+		}
+		result = append(result, t)
+		if len(result) > page.limit() {
+			break
+		}
+	}
+	result, next := page.result(result)
+	return result, next, nil
+}
+
+// SearchTasks ищет задачи без ранжирования: без явной сортировки они упорядочены по дате
+func (ms *MemoryStore) SearchTasks(searchData SearchQueryData, page Page) ([]models.Task, string, error) {
+	if err := page.checkCursor(SortDate); err != nil {
+		return nil, "", err
+	}
+	result := ms.page(page, func(t models.Task) bool { return t.DeletedAt == "" && searchData.Matches(t) })
+	if len(result) > page.limit()+1 {
+		result = result[:page.limit()+1]
+	}
+	result, next := page.result(result)
+	return result, next, nil
+}
+
+// page возвращает подходящие задачи после курсора страницы в её порядке, как ORDER BY в TasksRepository
+func (ms *MemoryStore) page(page Page, filter func(t models.Task) bool) []models.Task {
+	result := ms.sorted(func(t models.Task) bool {
+		return filter(t) && (page.cursor == nil || page.compare(page.taskCursor(t), page.cursor) > 0)
+	})
+	sort.SliceStable(result, func(i, j int) bool {
+		return page.compare(page.taskCursor(result[i]), page.taskCursor(result[j])) < 0
+	})
+	return result
+}
+
+// sorted возвращает подходящие задачи в порядке date, time, как ORDER BY в TasksRepository
+func (ms *MemoryStore) sorted(filter func(t models.Task) bool) []models.Task {
+	defer ms.lock()()
+
+	result := []models.Task{}
+	for _, t := range ms.tasks {
+		if ms.owns(t.UserID) && filter(t) {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		if result[i].Time != result[j].Time {
+			return result[i].Time < result[j].Time
+		}
+		return taskID(result[i]) < taskID(result[j])
+	})
+	return result
+}
+
+func (ms *MemoryStore) UpdateTaskIn(t models.Task) error {
+	defer ms.lock()()
+
+	id := taskID(t)
+	old, ok := ms.task(id)
+	if !ok {
+		return nil
+	}
+	t.RepeatText = ""
+	t.DeletedAt = old.DeletedAt
+	t.UserID = old.UserID
+	ms.tasks[id] = t
+	return nil
+}
+
+func (ms *MemoryStore) UpdateTaskDate(t models.Task, newDate string) error {
+	defer ms.lock()()
+
+	id := taskID(t)
+	stored, ok := ms.task(id)
+	if !ok {
+		return nil
+	}
+	stored.Date = newDate
+	stored.Time = t.Time
+	stored.Remaining = t.Remaining
+	stored.RuleDate = t.RuleDate
+	ms.tasks[id] = stored
+	return nil
+}
+
+func (ms *MemoryStore) DeleteTask(id int) error {
+	defer ms.lock()()
+
+	t, ok := ms.task(id)
+	if ok && t.DeletedAt == "" {
+		t.DeletedAt = time.Now().UTC().Format(time.RFC3339)
+		ms.tasks[id] = t
+	}
+	return nil
+}
+
+func (ms *MemoryStore) PostTaskDone(id int, note string) (*models.Task, error) {
+	var next *models.Task
+	err := ms.inTx(func(tx *MemoryStore) error {
+		var err error
+		next, err = postTaskDone(tx, id, note)
+		return err
+	})
+	return next, err
+}
+
+func (ms *MemoryStore) AddTaskSkip(id int, date string) (models.Task, error) {
+	return addTaskSkip(ms, id, date)
+}
+
+func (ms *MemoryStore) RemoveTaskSkip(id int, date string) (models.Task, error) {
+	return removeTaskSkip(ms, id, date)
+}
+
+func (ms *MemoryStore) AddCompletion(t models.Task, note string) error {
+	defer ms.lock()()
+
+	ms.lastCompletionID++
+	ms.completions = append(ms.completions, models.Completion{
+		ID:          strconv.Itoa(ms.lastCompletionID),
+		TaskID:      t.ID,
+		Date:        t.Date,
+		Time:        t.Time,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+		Note:        note,
+		UserID:      t.UserID,
+	})
+	return nil
+}
+
+func (ms *MemoryStore) GetCompletions(taskID int) ([]models.Completion, error) {
+	defer ms.lock()()
+
+	id := strconv.Itoa(taskID)
+	result := []models.Completion{}
+	for _, c := range ms.completions {
+		if c.TaskID == id && ms.owns(c.UserID) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (ms *MemoryStore) SnapshotTask(id int) (string, time.Time, error) {
+	token, err := newUndoToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	defer ms.lock()()
+
+	t, ok := ms.task(id)
+	if !ok || t.DeletedAt != "" {
+		return "", time.Time{}, sql.ErrNoRows
+	}
+
+	now := time.Now()
+	for token, s := range ms.undo {
+		if taskID(s.task) == id || s.expires.Before(now) {
+			delete(ms.undo, token)
+		}
+	}
+
+	s := undoSnapshot{task: t, lastCompletionID: ms.lastTaskCompletionID(t.ID), expires: now.Add(UndoTTL)}
+	ms.undo[token] = s
+	return token, s.expires, nil
+}
+
+// lastTaskCompletionID возвращает номер последней записи истории задачи или 0
+func (ms *MemoryStore) lastTaskCompletionID(id string) int {
+	last := 0
+	for _, c := range ms.completions {
+		if n, _ := strconv.Atoi(c.ID); c.TaskID == id && n > last {
+			last = n
+		}
+	}
+	return last
+}
+
+func (ms *MemoryStore) UndoTask(id int, token string) error {
+	defer ms.lock()()
+
+	s, ok := ms.undo[token]
+	if !ok || taskID(s.task) != id || !ms.owns(s.task.UserID) || s.expires.Before(time.Now()) {
+		return ErrUndoTokenInvalid
+	}
+
+	ms.tasks[id] = s.task
+	completions := ms.completions[:0]
+	for _, c := range ms.completions {
+		if n, _ := strconv.Atoi(c.ID); c.TaskID != s.task.ID || n <= s.lastCompletionID {
+			completions = append(completions, c)
+		}
+	}
+	ms.completions = completions
+	delete(ms.undo, token)
+	return nil
+}
+
+func (ms *MemoryStore) GetDeletedTasks() ([]models.Task, error) {
+	result := ms.sorted(func(t models.Task) bool { return t.DeletedAt != "" })
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].DeletedAt != result[j].DeletedAt {
+			return result[i].DeletedAt > result[j].DeletedAt
+		}
+		return taskID(result[i]) > taskID(result[j])
+	})
+	if len(result) > limitConst {
+		result = result[:limitConst]
+	}
+	return result, nil
+}
+
+func (ms *MemoryStore) RestoreTask(id int) error {
+	defer ms.lock()()
+
+	t, ok := ms.task(id)
+	if !ok || t.DeletedAt == "" {
+		return sql.ErrNoRows
+	}
+	t.DeletedAt = ""
+	ms.tasks[id] = t
+	return nil
+}
+
+func (ms *MemoryStore) PurgeDeletedTask(id int) error {
+	defer ms.lock()()
+
+	t, ok := ms.task(id)
+	if !ok || t.DeletedAt == "" {
+		return sql.ErrNoRows
+	}
+	delete(ms.tasks, id)
+	return nil
+}
+
+func (ms *MemoryStore) PurgeTask(id int) error {
+	defer ms.lock()()
+
+	if _, ok := ms.task(id); ok {
+		delete(ms.tasks, id)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) PurgeDeletedBefore(before time.Time) (int64, error) {
+	defer ms.lock()()
+
+	limit := before.UTC().Format(time.RFC3339)
+	var n int64
+	for id, t := range ms.tasks {
+		if t.DeletedAt != "" && t.DeletedAt < limit && ms.owns(t.UserID) {
+			delete(ms.tasks, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// taskID возвращает числовой id задачи
+func taskID(t models.Task) int {
+	id, _ := strconv.Atoi(t.ID)
+	return id
+}
+
+func (ms *MemoryStore) AddUser(u models.User) (int, error) {
+	defer ms.lock()()
+
+	for _, existing := range ms.users {
+		if existing.Login == u.Login {
+			return 0, ErrUserExists
+		}
+	}
+	ms.lastUserID++
+	u.ID = strconv.Itoa(ms.lastUserID)
+	u.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	ms.users[ms.lastUserID] = u
+	return ms.lastUserID, nil
+}
+
+func (ms *MemoryStore) GetUser(id int) (models.User, error) {
+	defer ms.lock()()
+
+	u, ok := ms.users[id]
+	if !ok {
+		return models.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (ms *MemoryStore) GetUserByLogin(login string) (models.User, error) {
+	defer ms.lock()()
+
+	for _, u := range ms.users {
+		if u.Login == login {
+			return u, nil
+		}
+	}
+	return models.User{}, sql.ErrNoRows
+}
+
+func (ms *MemoryStore) UpdateUserPassword(id int, passwordHash string) error {
+	defer ms.lock()()
+
+	u, ok := ms.users[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.PasswordHash = passwordHash
+	ms.users[id] = u
+	return nil
+}
+
+func (ms *MemoryStore) RevokeToken(jti string, expires time.Time) error {
+	defer ms.lock()()
+
+	now := time.Now()
+	for id, exp := range ms.revoked {
+		if exp.Before(now) {
+			delete(ms.revoked, id)
+		}
+	}
+	if _, ok := ms.revoked[jti]; ok {
+		return ErrTokenRevoked
+	}
+	ms.revoked[jti] = expires
+	return nil
+}
+
+func (ms *MemoryStore) IsTokenRevoked(jti string) (bool, error) {
+	defer ms.lock()()
+
+	_, ok := ms.revoked[jti]
+	return ok, nil
+}
+
+func (ms *MemoryStore) AddAPIKey(k models.APIKey) (int, error) {
+	defer ms.lock()()
+
+	ms.lastAPIKeyID++
+	k.ID = strconv.Itoa(ms.lastAPIKeyID)
+	k.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	k.LastUsed = ""
+	ms.apiKeys[ms.lastAPIKeyID] = k
+	return ms.lastAPIKeyID, nil
+}
+
+func (ms *MemoryStore) GetAPIKeys(userID int) ([]models.APIKey, error) {
+	defer ms.lock()()
+
+	keys := []models.APIKey{}
+	for id := 1; id <= ms.lastAPIKeyID; id++ {
+		if k, ok := ms.apiKeys[id]; ok && k.UserID == userID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (ms *MemoryStore) GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	defer ms.lock()()
+
+	for _, k := range ms.apiKeys {
+		if k.KeyHash == keyHash {
+			return k, nil
+		}
+	}
+	return models.APIKey{}, sql.ErrNoRows
+}
+
+func (ms *MemoryStore) TouchAPIKey(id int, used time.Time) error {
+	defer ms.lock()()
+
+	if k, ok := ms.apiKeys[id]; ok {
+		k.LastUsed = used.UTC().Format(time.RFC3339)
+		ms.apiKeys[id] = k
+	}
+	return nil
+}
+
+func (ms *MemoryStore) DeleteAPIKey(userID int, id int) error {
+	defer ms.lock()()
+
+	if k, ok := ms.apiKeys[id]; !ok || k.UserID != userID {
+		return sql.ErrNoRows
+	}
+	delete(ms.apiKeys, id)
+	return nil
+}
@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// maxPageLimit — наибольшее число задач на одной странице
+const maxPageLimit = 100
+
+// Порядок задач в выдаче
+const (
+	SortDate  = "date"  // по дате и времени
+	SortTitle = "title" // по заголовку
+	SortID    = "id"    // по идентификатору, то есть по времени создания
+
+	sortRank = "rank" // по релевантности текстового поиска, задаётся только по умолчанию
+)
+
+// ErrInvalidPage — неверные параметры страницы: limit, sort, order или cursor
+var ErrInvalidPage = errors.New("invalid page parameters")
+
+// Page — параметры страницы выдачи. Нулевое значение — первые limitConst задач по дате.
+// Следующая страница запрашивается курсором, который хранилище возвращает вместе с задачами.
+type Page struct {
+	Limit  int
+	Sort   string // SortDate, SortTitle или SortID; пусто — по дате, а при текстовом поиске по релевантности
+	Desc   bool
+	cursor *pageCursor
+}
+
+// pageCursor — позиция последней задачи страницы: значения столбцов сортировки
+// или, для сортировки по релевантности, число уже выданных задач
+type pageCursor struct {
+	Sort   string `json:"s"`
+	Date   string `json:"d,omitempty"`
+	Time   string `json:"t,omitempty"`
+	Title  string `json:"n,omitempty"`
+	ID     int    `json:"i,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+// NewPage разбирает параметры страницы из запроса; пустые параметры означают значения по умолчанию
+func NewPage(limit, sort, order, cursor string) (Page, error) {
+	var p Page
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return Page{}, fmt.Errorf("%w: limit must be from 1 to %d", ErrInvalidPage, maxPageLimit)
+		}
+		p.Limit = n
+	}
+
+	switch sort {
+	case "", SortDate, SortTitle, SortID:
+		p.Sort = sort
+	default:
+		return Page{}, fmt.Errorf("%w: unknown sort %s", ErrInvalidPage, sort)
+	}
+
+	switch order {
+	case "", "asc":
+	case "desc":
+		p.Desc = true
+	default:
+		return Page{}, fmt.Errorf("%w: unknown order %s", ErrInvalidPage, order)
+	}
+
+	if cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return Page{}, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		p.cursor = &pageCursor{}
+		if err := json.Unmarshal(data, p.cursor); err != nil {
+			return Page{}, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+	}
+	return p, nil
+}
+
+func (p Page) limit() int {
+	if p.Limit == 0 {
+		return limitConst
+	}
+	return p.Limit
+}
+
+// sortKey — сортировка с направлением; курсор действует только для той сортировки, с которой он получен
+func (p Page) sortKey(defaultSort string) string {
+	sort := p.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	if p.Desc {
+		return "-" + sort
+	}
+	return sort
+}
+
+// checkCursor проверяет, что курсор получен с той же сортировкой
+func (p Page) checkCursor(defaultSort string) error {
+	if p.cursor != nil && p.cursor.Sort != p.sortKey(defaultSort) {
+		return fmt.Errorf("%w: cursor belongs to another sort order", ErrInvalidPage)
+	}
+	return nil
+}
+
+// keyColumns — столбцы сортировки; id в конце делает порядок однозначным
+func (p Page) keyColumns() []string {
+	switch p.Sort {
+	case SortTitle:
+		return []string{"scheduler.title", "scheduler.id"}
+	case SortID:
+		return []string{"scheduler.id"}
+	default:
+		return []string{"scheduler.date", "scheduler.time", "scheduler.id"}
+	}
+}
+
+// keyValues — значения столбцов keyColumns в курсоре
+func (p Page) keyValues(c *pageCursor) []any {
+	switch p.Sort {
+	case SortTitle:
+		return []any{c.Title, c.ID}
+	case SortID:
+		return []any{c.ID}
+	default:
+		return []any{c.Date, c.Time, c.ID}
+	}
+}
+
+// orderBy — выражение ORDER BY для сортировки страницы
+func (p Page) orderBy() string {
+	columns := p.keyColumns()
+	if p.Desc {
+		for i := range columns {
+			columns[i] += " DESC"
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// afterCursor — условие отбора задач после курсора (сравнение строк значений) и его параметры.
+// Без курсора условие пустое.
+func (p Page) afterCursor() (string, []any) {
+	if p.cursor == nil {
+		return "", nil
+	}
+	columns, values := p.keyColumns(), p.keyValues(p.cursor)
+	names := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i := range columns {
+		names[i] = fmt.Sprintf(":cursor%d", i)
+		args[i] = sql.Named(fmt.Sprintf("cursor%d", i), values[i])
+	}
+	op := ">"
+	if p.Desc {
+		op = "<"
+	}
+	return " AND (" + strings.Join(columns, ", ") + ") " + op + " (" + strings.Join(names, ", ") + ")", args
+}
+
+// compare сравнивает задачи в порядке сортировки страницы, как ORDER BY в SQL
+func (p Page) compare(a, b *pageCursor) int {
+	var result int
+	switch p.Sort {
+	case SortTitle:
+		result = cmp.Or(strings.Compare(a.Title, b.Title), cmp.Compare(a.ID, b.ID))
+	case SortID:
+		result = cmp.Compare(a.ID, b.ID)
+	default:
+		result = cmp.Or(strings.Compare(a.Date, b.Date), strings.Compare(a.Time, b.Time), cmp.Compare(a.ID, b.ID))
+	}
+	if p.Desc {
+		return -result
+	}
+	return result
+}
+
+// taskCursor — позиция задачи для курсора
+func (p Page) taskCursor(t models.Task) *pageCursor {
+	c := &pageCursor{Sort: p.sortKey(SortDate), ID: taskID(t)}
+	switch p.Sort {
+	case SortTitle:
+		c.Title = t.Title
+	case SortID:
+	default:
+		c.Date, c.Time = t.Date, t.Time
+	}
+	return c
+}
+
+// result обрезает выбранные с запасом в одну задачу строки до размера страницы
+// и возвращает курсор следующей страницы, если задачи ещё есть
+func (p Page) result(tasks []models.Task) ([]models.Task, string) {
+	if len(tasks) <= p.limit() {
+		return tasks, ""
+	}
+	tasks = tasks[:p.limit()]
+	return tasks, encodeCursor(p.taskCursor(tasks[len(tasks)-1]))
+}
+
+func encodeCursor(c *pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
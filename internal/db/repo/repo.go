@@ -1,49 +1,33 @@
 package repo
 
 import (
-	"fmt"
-	"time"
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/models"
 )
 
-// структуры, методы и интерфейс для абстрагирования параметров поиска
-type DateSearchParam struct {
-	Date time.Time
-}
-
-func (dp *DateSearchParam) GetQueryData() *QueryData {
-	return &QueryData{
-		Param:     dp.Date.Format(timeTemplate),
-		Condition: "WHERE date LIKE :search",
-	}
-}
-
-type TextSearchParam struct {
-	Text string
-}
-
-func (tp *TextSearchParam) GetQueryData() *QueryData {
-	return &QueryData{
-		Param:     fmt.Sprintf("%%%s%%", tp.Text),
-		Condition: "WHERE title LIKE :search OR comment LIKE :search",
-	}
-}
-
+// SearchQueryData — параметр поиска: условие для SQL хранилищ (GetQueryData) и проверка задачи для хранилища в памяти (Matches)
 type SearchQueryData interface {
-	GetQueryData() *QueryData
+	GetQueryData(dialect db.Dialect) *QueryData
+	Matches(t models.Task) bool
 }
 
-func QueryDataFromString(search string) SearchQueryData {
-	searchDate, err := time.Parse("02.01.2006", search)
+// QueryDataFromString разбирает строку поиска в фильтр (см. ParseFilter)
+func QueryDataFromString(search string) (SearchQueryData, error) {
+	f, err := ParseFilter(search)
 	if err != nil {
-		return &TextSearchParam{Text: search}
-	} else {
-		return &DateSearchParam{Date: searchDate}
+		return nil, err
 	}
+	return f, nil
 }
 
+// QueryData — параметры и условие поиска; условие подставляется в WHERE вместе с отбором неудалённых задач.
+// Столбцы scheduler в выражениях указываются с именем таблицы, так как к ней может присоединяться Join.
 type QueryData struct {
-	Param     string
+	Args      []any // именованные параметры условия (sql.Named)
 	Condition string
+	Join      string // присоединение таблицы полнотекстового индекса
+	OrderBy   string // порядок выдачи, по умолчанию по дате и времени
+	Snippet   string // выражение для фрагмента текста с подсвеченными словами, по умолчанию пустое
 }
 
 // ---------------------------
@@ -2,11 +2,10 @@ package repo
 
 import (
 	"database/sql"
-	"fmt"
 	"strings"
 	"time"
 
-	"github.com/wisdomdevil/go_final_project/internal/dateutil"
+	"github.com/wisdomdevil/go_final_project/internal/db"
 	"github.com/wisdomdevil/go_final_project/internal/models"
 )
 
@@ -15,79 +14,127 @@ const (
 	timeTemplate = "20060102"
 )
 
-// чтобы оперировать Tasks (TaskCreationRequest), нужна всегда ссылка на БД
+// taskColumns — столбцы таблицы scheduler в порядке, который ожидает scanTask
+const taskColumns = "id, date, title, comment, repeat, remaining, skip, time, timezone, anchor, deleted_at, user_id, rule_date"
+
+// notDeleted — условие отбора задач, которые не лежат в корзине
+const notDeleted = "deleted_at = ''"
+
+// ownTasks — условие отбора задач пользователя хранилища (параметр user_id, см. TasksRepository.owner).
+// Хранилище без пользователя (user_id 0) видит задачи всех пользователей.
+const ownTasks = "(:user_id = 0 OR scheduler.user_id = :user_id)"
+
+// rowScanner — общий метод Scan у *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask заполняет задачу данными строки, выбранной со столбцами taskColumns.
+// Дополнительные столбцы после них читаются в extra.
+func scanTask(row rowScanner, t *models.Task, extra ...any) error {
+	dest := []any{&t.ID, &t.Date, &t.Title, &t.Comment, &t.Repeat, &t.Remaining,
+		&t.Skip, &t.Time, &t.Timezone, &t.Anchor, &t.DeletedAt, &t.UserID, &t.RuleDate}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// qualifiedTaskColumns возвращает taskColumns с именем таблицы scheduler, для запросов с JOIN
+func qualifiedTaskColumns() string {
+	return "scheduler." + strings.ReplaceAll(taskColumns, ", ", ", scheduler.")
+}
+
+// чтобы оперировать Tasks (TaskCreationRequest), нужна всегда ссылка на БД.
+// TasksRepository — хранилище задач в SQL БД: SQLite или PostgreSQL, в зависимости от диалекта подключения.
+// Запросы ограничены задачами пользователя userID (см. ForUser).
+// Запросы выполняются через conn: это само подключение или транзакция, открытая inTx.
 type TasksRepository struct {
-	db *sql.DB
+	db     *db.DB
+	conn   db.Querier
+	userID int
 }
 
-func NewTasksRepository(db *sql.DB) TasksRepository {
-	return TasksRepository{db: db}
+// NewTasksRepository создаёт хранилище задач всех пользователей, например для фоновой очистки корзины
+func NewTasksRepository(conn *db.DB) TasksRepository {
+	return TasksRepository{db: conn, conn: conn}
 }
 
-func (tr TasksRepository) AddTask(t models.Task) (int, error) {
-	task, err := tr.db.Exec("INSERT INTO scheduler (date, title, comment, repeat) VALUES (:date, :title, :comment, :repeat)",
-		sql.Named("date", t.Date),
-		sql.Named("title", t.Title),
-		sql.Named("comment", t.Comment),
-		sql.Named("repeat", t.Repeat))
+// ForUser возвращает хранилище, которое видит и создаёт только задачи пользователя userID
+func (tr TasksRepository) ForUser(userID int) TaskStore {
+	return TasksRepository{db: tr.db, conn: tr.conn, userID: userID}
+}
 
+// inTx выполняет f с копией хранилища, все запросы которой идут в одной транзакции.
+// Транзакция фиксируется, если f не вернула ошибку.
+func (tr TasksRepository) inTx(f func(tx TasksRepository) error) error {
+	tx, err := tr.db.Begin()
 	if err != nil {
-		return 0, err
+		return err
 	}
+	defer tx.Rollback()
 
-	id, err := task.LastInsertId()
-	if err != nil {
-		return 0, err
+	if err := f(TasksRepository{db: tr.db, conn: tx, userID: tr.userID}); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
 
-	// возвращаем идентификатор последней добавленной записи
-	return int(id), nil
+// owner — параметр user_id для условия ownTasks
+func (tr TasksRepository) owner() sql.NamedArg {
+	return sql.Named("user_id", tr.userID)
 }
 
-// PostTaskDone moves task according the repeat rule
-func (tr TasksRepository) PostTaskDone(id int) (*models.Task, error) {
-	t, err := tr.GetTask(id)
-	if err != nil {
-		return nil, err
-	}
+func (tr TasksRepository) AddTask(t models.Task) (int, error) {
+	id, err := tr.conn.InsertReturningID("INSERT INTO scheduler (date, title, comment, repeat, remaining, skip, time, timezone, anchor, user_id, rule_date) "+
+		"VALUES (:date, :title, :comment, :repeat, :remaining, :skip, :time, :timezone, :anchor, :user_id, :rule_date)",
+		sql.Named("date", t.Date),
+		sql.Named("title", t.Title),
+		sql.Named("comment", t.Comment),
+		sql.Named("repeat", t.Repeat),
+		sql.Named("remaining", t.Remaining),
+		sql.Named("skip", t.Skip),
+		sql.Named("time", t.Time),
+		sql.Named("timezone", t.Timezone),
+		sql.Named("anchor", t.Anchor),
+		tr.owner(),
+		sql.Named("rule_date", t.RuleDate))
 
-	dt, err := time.Parse(timeTemplate, t.Date)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	if t.Repeat == "" {
-		fmt.Println("Repeat is null")
-		err = tr.DeleteTask(id)
-		if err != nil {
-			return nil, err
-		}
-		return nil, nil
-	}
-	// далее else
-	fmt.Println("Repeat is not null")
-	now := time.Now()
-	nextDate, err := dateutil.NextDate(now, dt, t.Repeat)
-	if err != nil {
-		return nil, err
-	}
-	err = tr.UpdateTaskDate(t, nextDate)
-	if err != nil {
-		return nil, err
-	}
+	// возвращаем идентификатор последней добавленной записи
+	return int(id), nil
+}
 
-	return &t, nil
+// PostTaskDone moves task according the repeat rule.
+// Выполнение записывается в историю (task_completions) с датой по расписанию и заметкой note.
+func (tr TasksRepository) PostTaskDone(id int, note string) (*models.Task, error) {
+	// перенос или удаление задачи и запись в историю выполняются вместе: без записи выполнение потеряется
+	var next *models.Task
+	err := tr.inTx(func(tx TasksRepository) error {
+		var err error
+		next, err = postTaskDone(tx, id, note)
+		return err
+	})
+	return next, err
 }
 
 // UpdateTask - put Method, updates task in DB.
 func (tr TasksRepository) UpdateTaskIn(t models.Task) error {
-	_, err := tr.db.Exec("UPDATE scheduler SET date = :date, title = :title, comment = :comment,"+
-		"repeat = :repeat WHERE id = :id",
+	_, err := tr.conn.Exec("UPDATE scheduler SET date = :date, title = :title, comment = :comment,"+
+		"repeat = :repeat, remaining = :remaining, skip = :skip, time = :time, timezone = :timezone, "+
+		"anchor = :anchor, rule_date = :rule_date WHERE id = :id AND "+ownTasks,
 		sql.Named("date", t.Date),
 		sql.Named("title", t.Title),
 		sql.Named("comment", t.Comment),
 		sql.Named("repeat", t.Repeat),
-		sql.Named("id", t.ID))
+		sql.Named("remaining", t.Remaining),
+		sql.Named("skip", t.Skip),
+		sql.Named("time", t.Time),
+		sql.Named("timezone", t.Timezone),
+		sql.Named("anchor", t.Anchor),
+		sql.Named("rule_date", t.RuleDate),
+		sql.Named("id", t.ID),
+		tr.owner())
 
 	if err != nil {
 		return err
@@ -100,11 +147,12 @@ func (tr TasksRepository) UpdateTaskIn(t models.Task) error {
 // Из таблицы должна вернуться только одна строка.
 func (tr TasksRepository) GetTask(id int) (models.Task, error) {
 	s := models.Task{}
-	row := tr.db.QueryRow("SELECT id, date, title, comment, repeat from scheduler WHERE id = :id",
-		sql.Named("id", id))
+	row := tr.conn.QueryRow("SELECT "+taskColumns+" FROM scheduler WHERE id = :id AND "+notDeleted+" AND "+ownTasks,
+		sql.Named("id", id),
+		tr.owner())
 
 	// заполняем объект TaskCreationRequest данными из таблицы
-	err := row.Scan(&s.ID, &s.Date, &s.Title, &s.Comment, &s.Repeat)
+	err := scanTask(row, &s)
 	if err != nil {
 		return models.Task{}, err
 	}
@@ -112,16 +160,22 @@ func (tr TasksRepository) GetTask(id int) (models.Task, error) {
 }
 
 // Из таблицы должны вернуться сроки с ближайшими датами.
-func (tr TasksRepository) GetAllTasks() ([]models.Task, error) {
-	today := time.Now().Format(timeTemplate)
+// Сегодняшняя дата у каждой задачи своя (в её часовом поясе), поэтому из БД выбираются задачи
+// начиная с самой ранней возможной сегодняшней даты, а лишние отбрасываются по часовому поясу задачи.
+// Возвращается страница задач и курсор следующей страницы (пустой, если задач больше нет).
+func (tr TasksRepository) GetAllTasks(page Page) ([]models.Task, string, error) {
+	if err := page.checkCursor(SortDate); err != nil {
+		return nil, "", err
+	}
+	earliestToday := time.Now().UTC().AddDate(0, 0, -1).Format(timeTemplate)
+	after, args := page.afterCursor()
 
-	rows, err := tr.db.Query("SELECT id, date, title, comment, repeat FROM scheduler WHERE date >= :today "+
-		"ORDER BY date LIMIT :limit",
-		sql.Named("today", today),
-		sql.Named("limit", limitConst))
+	rows, err := tr.conn.Query("SELECT "+qualifiedTaskColumns()+" FROM scheduler "+
+		"WHERE scheduler.date >= :today AND scheduler."+notDeleted+" AND "+ownTasks+after+" ORDER BY "+page.orderBy(),
+		append(args, sql.Named("today", earliestToday), tr.owner())...)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer rows.Close()
@@ -130,40 +184,79 @@ func (tr TasksRepository) GetAllTasks() ([]models.Task, error) {
 	// заполняем объект Task данными из таблицы
 	for rows.Next() { // пока есть записи
 		s := models.Task{} // создаем новый объект  Task и заполняем его данными из текущего row
-		err := rows.Scan(&s.ID, &s.Date, &s.Title, &s.Comment, &s.Repeat)
+		err := scanTask(rows, &s)
 		if err != nil {
-			return nil, err
+			return nil, "", err
+		}
+		if !s.IsUpcoming() {
+			continue
 		}
 		result = append(result, s)
+		// одна лишняя задача показывает, что есть следующая страница
+		if len(result) > page.limit() {
+			break
+		}
 	}
 	//Проверяем успешное завершение цикла
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return result, nil
+	result, next := page.result(result)
+	return result, next, nil
 }
 
 // ---------------------------
 
 // Из таблицы должна вернуться срока в соответсвии с критерием поиска search.
-func (tr TasksRepository) SearchTasks(searchData SearchQueryData) ([]models.Task, error) {
+// Без явной сортировки текстовый поиск упорядочен по релевантности, и курсор хранит число выданных задач;
+// order=desc без sort — обратный порядок по дате.
+func (tr TasksRepository) SearchTasks(searchData SearchQueryData, page Page) ([]models.Task, string, error) {
 	var rows *sql.Rows
 
-	queryData := searchData.GetQueryData()
+	queryData := searchData.GetQueryData(tr.db.Dialect)
+	if queryData.Snippet == "" {
+		queryData.Snippet = "''"
+	}
+
+	byRank := page.Sort == "" && !page.Desc && queryData.OrderBy != ""
+	defaultSort := SortDate
+	if byRank {
+		defaultSort = sortRank
+	}
+	if err := page.checkCursor(defaultSort); err != nil {
+		return nil, "", err
+	}
+
+	offset := 0
+	after, args := "", queryData.Args
+	if byRank {
+		if page.cursor != nil {
+			offset = page.cursor.Offset
+		}
+		queryData.OrderBy += ", scheduler.id"
+	} else {
+		var cursorArgs []any
+		after, cursorArgs = page.afterCursor()
+		args = append(args, cursorArgs...)
+		queryData.OrderBy = page.orderBy()
+	}
 
+	// при текстовом поиске задачи дополнены фрагментом текста
 	querySQL := strings.Join([]string{
-		"SELECT id, date, title, comment, repeat FROM scheduler",
-		queryData.Condition,
-		"ORDER BY date LIMIT :limit",
+		"SELECT " + qualifiedTaskColumns() + ", " + queryData.Snippet + " FROM scheduler",
+		queryData.Join,
+		"WHERE scheduler." + notDeleted + " AND " + ownTasks + " AND (" + queryData.Condition + ")" + after,
+		"ORDER BY " + queryData.OrderBy + " LIMIT :limit OFFSET :offset",
 	}, " ")
 
-	rows, err := tr.db.Query(querySQL,
-		sql.Named("search", queryData.Param),
-		sql.Named("limit", limitConst))
+	rows, err := tr.conn.Query(querySQL, append(args,
+		tr.owner(),
+		sql.Named("limit", page.limit()+1),
+		sql.Named("offset", offset))...)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer rows.Close()
@@ -173,23 +266,35 @@ func (tr TasksRepository) SearchTasks(searchData SearchQueryData) ([]models.Task
 	for rows.Next() { // пока есть записи
 		s := models.Task{} // создаем новый объект  Task и заполняем его данными из текущего row
 
-		if err := rows.Scan(&s.ID, &s.Date, &s.Title, &s.Comment, &s.Repeat); err != nil {
-			return nil, err
+		if err := scanTask(rows, &s, &s.Snippet); err != nil {
+			return nil, "", err
 		}
+		s.Snippet = highlightSnippet(s.Snippet)
 		result = append(result, s)
 	}
 	//Проверяем успешное завершение цикла
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return result, nil
+	if byRank {
+		if len(result) <= page.limit() {
+			return result, "", nil
+		}
+		next := &pageCursor{Sort: page.sortKey(sortRank), Offset: offset + page.limit()}
+		return result[:page.limit()], encodeCursor(next), nil
+	}
+	result, next := page.result(result)
+	return result, next, nil
 }
 
-// Удаление строки по заданному id.
+// Удаление задачи в корзину по заданному id: строка остаётся в БД с отметкой deleted_at
+// и окончательно удаляется через PurgeTask или PurgeDeletedBefore.
 func (tr TasksRepository) DeleteTask(id int) error {
-	_, err := tr.db.Exec("DELETE FROM scheduler WHERE id = :id",
-		sql.Named("id", id))
+	_, err := tr.conn.Exec("UPDATE scheduler SET deleted_at = :deleted_at WHERE id = :id AND "+notDeleted+" AND "+ownTasks,
+		sql.Named("deleted_at", time.Now().UTC().Format(time.RFC3339)),
+		sql.Named("id", id),
+		tr.owner())
 	if err != nil {
 		return err
 	}
@@ -198,10 +303,16 @@ func (tr TasksRepository) DeleteTask(id int) error {
 }
 
 // UpdateTask updates task in DB according the new date by the rule in repeat.
+// Время (для правил h, min, t) и остаток повторений (remaining) сохраняются вместе с датой.
 func (tr TasksRepository) UpdateTaskDate(t models.Task, newDate string) error {
-	_, err := tr.db.Exec("UPDATE scheduler SET date = :date WHERE id = :id",
+	_, err := tr.conn.Exec("UPDATE scheduler SET date = :date, time = :time, remaining = :remaining, rule_date = :rule_date "+
+		"WHERE id = :id AND "+ownTasks,
 		sql.Named("date", newDate),
-		sql.Named("id", t.ID))
+		sql.Named("time", t.Time),
+		sql.Named("remaining", t.Remaining),
+		sql.Named("rule_date", t.RuleDate),
+		sql.Named("id", t.ID),
+		tr.owner())
 
 	if err != nil {
 		return err
@@ -209,3 +320,14 @@ func (tr TasksRepository) UpdateTaskDate(t models.Task, newDate string) error {
 
 	return nil
 }
+
+// AddTaskSkip добавляет задаче дату-исключение.
+// Если исключается текущая дата задачи, задача переносится на следующую дату по правилу.
+func (tr TasksRepository) AddTaskSkip(id int, date string) (models.Task, error) {
+	return addTaskSkip(tr, id, date)
+}
+
+// RemoveTaskSkip удаляет у задачи дату-исключение
+func (tr TasksRepository) RemoveTaskSkip(id int, date string) (models.Task, error) {
+	return removeTaskSkip(tr, id, date)
+}
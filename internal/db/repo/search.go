@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Границы подсвеченных слов, которые БД ставит во фрагмент найденной задачи. Это управляющие символы,
+// а не теги: фрагмент содержит текст задачи как есть, и теги в нём появляются только после экранирования,
+// см. highlightSnippet.
+const (
+	snippetStart = "\x02"
+	snippetStop  = "\x03"
+)
+
+// highlightSnippet экранирует фрагмент из БД как HTML и заменяет границы подсвеченных слов на <mark>,
+// чтобы теги из названия или комментария задачи не попали в разметку клиента
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(snippet)
+}
+
+// pgSearchVector — текст задачи для полнотекстового поиска в PostgreSQL, по нему построен индекс scheduler_search
+const pgSearchVector = "to_tsvector('simple', scheduler.title || ' ' || COALESCE(scheduler.comment, ''))"
+
+// searchTerm — условие полнотекстового поиска: одно слово или фраза из нескольких слов подряд.
+// prefix означает, что последнее слово ищется по началу.
+type searchTerm struct {
+	words  []string
+	prefix bool
+}
+
+// splitSearchTokens делит строку поиска на части по пробелам, кроме пробелов внутри кавычек
+func splitSearchTokens(text string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range text {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if unicode.IsSpace(r) && !quoted {
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// parseSearchTerm разбирает слово, "фразу в кавычках" или слово* для поиска по началу.
+// В условии остаются только буквы и цифры, поэтому его можно безопасно подставить в язык запросов FTS.
+func parseSearchTerm(raw string) (searchTerm, bool) {
+	prefix := strings.HasSuffix(raw, "*")
+	words := searchWords(raw)
+	return searchTerm{words: words, prefix: prefix}, len(words) > 0
+}
+
+// searchWords делит текст на слова в нижнем регистре
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matches проверяет, есть ли условие среди слов текста
+func (st searchTerm) matches(words []string) bool {
+	for i := 0; i+len(st.words) <= len(words); i++ {
+		found := true
+		for j, w := range st.words {
+			last := j == len(st.words)-1
+			if words[i+j] != w && !(last && st.prefix && strings.HasPrefix(words[i+j], w)) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// ftsQuery собирает запрос FTS5: "слово" "фраза из слов" "начало"*, условия объединяются через AND.
+// Если задан column, каждое условие ищется только в этом столбце.
+func ftsQuery(terms []searchTerm, column string) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		part := `"` + strings.Join(t.words, " ") + `"`
+		if column != "" {
+			part = column + " : " + part
+		}
+		if t.prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// tsQuery собирает запрос to_tsquery PostgreSQL: 'слово' & 'фраза' <-> 'из' <-> 'слов' & 'начало':*
+func tsQuery(terms []searchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		words := make([]string, 0, len(t.words))
+		for _, w := range t.words {
+			words = append(words, "'"+w+"'")
+		}
+		if t.prefix {
+			words[len(words)-1] += ":*"
+		}
+		parts = append(parts, strings.Join(words, " <-> "))
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// TaskStore — хранилище задач, с которым работают хэндлеры.
+// Реализация TasksRepository работает с SQLite и PostgreSQL.
+// Хэндлеры работают с хранилищем пользователя из запроса (ForUser), а не со всеми задачами.
+type TaskStore interface {
+	// ForUser возвращает хранилище задач одного пользователя
+	ForUser(userID int) TaskStore
+
+	// задачи
+	AddTask(t models.Task) (int, error)
+	GetTask(id int) (models.Task, error)
+	GetAllTasks(page Page) ([]models.Task, string, error)
+	SearchTasks(searchData SearchQueryData, page Page) ([]models.Task, string, error)
+	UpdateTaskIn(t models.Task) error
+	UpdateTaskDate(t models.Task, newDate string) error
+	DeleteTask(id int) error
+	PostTaskDone(id int, note string) (*models.Task, error)
+
+	// даты-исключения
+	AddTaskSkip(id int, date string) (models.Task, error)
+	RemoveTaskSkip(id int, date string) (models.Task, error)
+
+	// история выполнений
+	AddCompletion(t models.Task, note string) error
+	GetCompletions(taskID int) ([]models.Completion, error)
+
+	// отмена выполнения и удаления
+	SnapshotTask(id int) (string, time.Time, error)
+	UndoTask(id int, token string) error
+
+	// корзина
+	GetDeletedTasks() ([]models.Task, error)
+	RestoreTask(id int) error
+	PurgeDeletedTask(id int) error
+	PurgeTask(id int) error
+	PurgeDeletedBefore(before time.Time) (int64, error)
+}
+
+var _ TaskStore = TasksRepository{}
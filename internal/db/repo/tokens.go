@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrTokenRevoked — токен уже отозван. RevokeToken возвращает её, если токен отозвали раньше, например
+// параллельным запросом с тем же токеном обновления: отзыв и есть проверка, что токен используется один раз.
+var ErrTokenRevoked = errors.New("token is revoked")
+
+// TokenStore — список отозванных токенов. Токен хранится до истечения его срока, после этого он недействителен и так.
+type TokenStore interface {
+	RevokeToken(jti string, expires time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+// AccountStore — учётные записи пользователей, их токены и API-ключи
+type AccountStore interface {
+	UserStore
+	TokenStore
+	APIKeyStore
+}
+
+var (
+	_ AccountStore = TasksRepository{}
+	_ AccountStore = (*MemoryStore)(nil)
+)
+
+// RevokeToken добавляет токен в список отозванных или возвращает ErrTokenRevoked, если он уже там.
+// Заодно удаляются записи об уже истёкших токенах.
+func (tr TasksRepository) RevokeToken(jti string, expires time.Time) error {
+	_, err := tr.conn.Exec("DELETE FROM revoked_tokens WHERE expires_at < :now",
+		sql.Named("now", time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+
+	res, err := tr.conn.Exec("INSERT INTO revoked_tokens (jti, expires_at) VALUES (:jti, :expires_at) "+
+		"ON CONFLICT (jti) DO NOTHING",
+		sql.Named("jti", jti),
+		sql.Named("expires_at", expires.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// IsTokenRevoked сообщает, отозван ли токен
+func (tr TasksRepository) IsTokenRevoked(jti string) (bool, error) {
+	var n int
+	err := tr.conn.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = :jti", sql.Named("jti", jti)).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
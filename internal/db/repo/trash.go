@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// GetDeletedTasks возвращает задачи из корзины, недавно удалённые первыми
+func (tr TasksRepository) GetDeletedTasks() ([]models.Task, error) {
+	rows, err := tr.conn.Query("SELECT "+taskColumns+" FROM scheduler "+
+		"WHERE deleted_at != '' AND "+ownTasks+" ORDER BY deleted_at DESC, id DESC LIMIT :limit",
+		sql.Named("limit", limitConst),
+		tr.owner())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Task{}
+	for rows.Next() {
+		s := models.Task{}
+		if err := scanTask(rows, &s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RestoreTask возвращает задачу из корзины. Если в корзине её нет, возвращается sql.ErrNoRows.
+func (tr TasksRepository) RestoreTask(id int) error {
+	res, err := tr.conn.Exec("UPDATE scheduler SET deleted_at = '' WHERE id = :id AND deleted_at != '' AND "+ownTasks,
+		sql.Named("id", id),
+		tr.owner())
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// PurgeDeletedTask окончательно удаляет задачу из корзины. Если в корзине её нет, возвращается sql.ErrNoRows.
+func (tr TasksRepository) PurgeDeletedTask(id int) error {
+	res, err := tr.conn.Exec("DELETE FROM scheduler WHERE id = :id AND deleted_at != '' AND "+ownTasks,
+		sql.Named("id", id),
+		tr.owner())
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// PurgeTask окончательно удаляет задачу, минуя корзину
+func (tr TasksRepository) PurgeTask(id int) error {
+	_, err := tr.conn.Exec("DELETE FROM scheduler WHERE id = :id AND "+ownTasks,
+		sql.Named("id", id),
+		tr.owner())
+	return err
+}
+
+// PurgeDeletedBefore окончательно удаляет задачи, попавшие в корзину раньше before,
+// и возвращает их количество
+func (tr TasksRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	res, err := tr.conn.Exec("DELETE FROM scheduler WHERE deleted_at != '' AND deleted_at < :before AND "+ownTasks,
+		sql.Named("before", before.UTC().Format(time.RFC3339)),
+		tr.owner())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// checkAffected возвращает sql.ErrNoRows, если запрос не изменил ни одной строки
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RunTrashPurge раз в interval окончательно удаляет из хранилища задачи, пролежавшие в корзине дольше ttl.
+// Запускается в отдельной горутине и работает до завершения приложения.
+func RunTrashPurge(store TaskStore, ttl time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := store.PurgeDeletedBefore(time.Now().Add(-ttl))
+		if err != nil {
+			log.Println("error in purging trash:", err)
+		} else if n > 0 {
+			log.Printf("Purged %d tasks from trash", n)
+		}
+		<-ticker.C
+	}
+}
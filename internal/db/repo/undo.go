@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// UndoTTL — сколько времени действует токен отмены
+const UndoTTL = 5 * time.Minute
+
+// ErrUndoTokenInvalid возвращается, если токена отмены нет, он истёк или выдан для другой задачи
+var ErrUndoTokenInvalid = errors.New("undo token is invalid or expired")
+
+// newUndoToken создаёт случайный токен отмены
+func newUndoToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SnapshotTask сохраняет текущее состояние строки задачи в task_undo перед выполнением или удалением
+// и возвращает токен отмены со временем его истечения. Действует только последний снимок задачи:
+// более ранние токены этой задачи, как и все истёкшие, удаляются.
+// Если задачи нет, возвращается sql.ErrNoRows.
+func (tr TasksRepository) SnapshotTask(id int) (string, time.Time, error) {
+	token, err := newUndoToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now().UTC()
+	expires := now.Add(UndoTTL)
+
+	tx, err := tr.db.Begin()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM task_undo WHERE id = :id OR expires_at < :now",
+		sql.Named("id", id),
+		sql.Named("now", now.Format(time.RFC3339)))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// вместе со строкой запоминаем последнюю запись истории, чтобы при отмене выполнения удалить новые
+	res, err := tx.Exec("INSERT INTO task_undo (token, expires_at, last_completion_id, "+taskColumns+") "+
+		"SELECT CAST(:token AS VARCHAR(64)), CAST(:expires_at AS VARCHAR(32)), "+
+		"(SELECT COALESCE(MAX(c.id), 0) FROM task_completions c WHERE c.task_id = :id), "+
+		taskColumns+" FROM scheduler WHERE id = :id AND "+notDeleted+" AND "+ownTasks,
+		sql.Named("token", token),
+		sql.Named("expires_at", expires.Format(time.RFC3339)),
+		sql.Named("id", id),
+		tr.owner())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return "", time.Time{}, sql.ErrNoRows
+	}
+
+	return token, expires, tx.Commit()
+}
+
+// UndoTask восстанавливает задачу из снимка по токену отмены и удаляет записи истории,
+// появившиеся после снимка. Токен одноразовый.
+func (tr TasksRepository) UndoTask(id int, token string) error {
+	tx, err := tr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lastCompletionID int64
+	err = tx.QueryRow("SELECT last_completion_id FROM task_undo WHERE token = :token AND id = :id AND expires_at >= :now "+
+		"AND (:user_id = 0 OR user_id = :user_id)",
+		sql.Named("token", token),
+		sql.Named("id", id),
+		sql.Named("now", time.Now().UTC().Format(time.RFC3339)),
+		tr.owner()).Scan(&lastCompletionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUndoTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	// строка задачи заменяется снимком целиком: после выполнения её могло уже не быть
+	_, err = tx.Exec("DELETE FROM scheduler WHERE id = :id", sql.Named("id", id))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO scheduler ("+taskColumns+") "+
+		"SELECT "+taskColumns+" FROM task_undo WHERE token = :token",
+		sql.Named("token", token))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM task_completions WHERE task_id = :id AND id > :last_id",
+		sql.Named("id", id),
+		sql.Named("last_id", lastCompletionID))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM task_undo WHERE token = :token", sql.Named("token", token))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// ErrUserExists возвращается при регистрации пользователя с занятым логином
+var ErrUserExists = errors.New("user already exists")
+
+// UserStore — хранилище учётных записей пользователей
+type UserStore interface {
+	AddUser(u models.User) (int, error)
+	GetUser(id int) (models.User, error)
+	GetUserByLogin(login string) (models.User, error)
+	UpdateUserPassword(id int, passwordHash string) error
+}
+
+// userColumns — столбцы таблицы users в порядке, который ожидает scanUser
+const userColumns = "id, login, password_hash, is_admin, created_at"
+
+func scanUser(row rowScanner, u *models.User) error {
+	var isAdmin int
+	if err := row.Scan(&u.ID, &u.Login, &u.PasswordHash, &isAdmin, &u.CreatedAt); err != nil {
+		return err
+	}
+	u.IsAdmin = isAdmin != 0
+	return nil
+}
+
+// AddUser регистрирует пользователя. Если логин занят, возвращается ErrUserExists.
+func (tr TasksRepository) AddUser(u models.User) (int, error) {
+	if _, err := tr.GetUserByLogin(u.Login); err == nil {
+		return 0, ErrUserExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	isAdmin := 0
+	if u.IsAdmin {
+		isAdmin = 1
+	}
+	id, err := tr.conn.InsertReturningID("INSERT INTO users (login, password_hash, is_admin, created_at) "+
+		"VALUES (:login, :password_hash, :is_admin, :created_at)",
+		sql.Named("login", u.Login),
+		sql.Named("password_hash", u.PasswordHash),
+		sql.Named("is_admin", isAdmin),
+		sql.Named("created_at", time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetUser возвращает пользователя по id или sql.ErrNoRows
+func (tr TasksRepository) GetUser(id int) (models.User, error) {
+	u := models.User{}
+	row := tr.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE id = :id", sql.Named("id", id))
+	if err := scanUser(row, &u); err != nil {
+		return models.User{}, err
+	}
+	return u, nil
+}
+
+// GetUserByLogin возвращает пользователя по логину или sql.ErrNoRows
+func (tr TasksRepository) GetUserByLogin(login string) (models.User, error) {
+	u := models.User{}
+	row := tr.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE login = :login", sql.Named("login", login))
+	if err := scanUser(row, &u); err != nil {
+		return models.User{}, err
+	}
+	return u, nil
+}
+
+// UpdateUserPassword сохраняет новый хэш пароля пользователя
+func (tr TasksRepository) UpdateUserPassword(id int, passwordHash string) error {
+	res, err := tr.conn.Exec("UPDATE users SET password_hash = :password_hash WHERE id = :id",
+		sql.Named("password_hash", passwordHash),
+		sql.Named("id", id))
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+const (
+	apiKeyPrefix        = "todo_"     // начало каждого API-ключа, по нему ключ отличается от JWT
+	apiKeyPrefixLength  = 12          // сколько первых символов ключа видно в списке ключей
+	maxAPIKeyNameLength = 128         // длина названия ключа в символах
+	apiKeyTouchInterval = time.Minute // last_used обновляется не чаще, чтобы не писать в БД на каждый запрос
+)
+
+// newAPIKey создаёт случайный API-ключ
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey — хэш ключа для хранения и поиска в БД. Ключ случайный и длинный, поэтому соль и bcrypt не нужны.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// bearerToken возвращает токен из заголовка Authorization: Bearer
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// userFromBearer находит пользователя по API-ключу или токену доступа из заголовка Authorization.
+// readOnly означает, что ключ разрешает только чтение.
+func (a *Api) userFromBearer(token string) (user models.User, readOnly bool, err error) {
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		claims, key, err := a.parseToken(token, tokenTypeAccess)
+		if err != nil {
+			return models.User{}, false, err
+		}
+		user, err = a.userFromClaims(claims, key)
+		return user, false, err
+	}
+
+	apiKey, err := a.users.GetAPIKeyByHash(hashAPIKey(token))
+	if err != nil {
+		return models.User{}, false, err
+	}
+	user, err = a.users.GetUser(apiKey.UserID)
+	if err != nil {
+		return models.User{}, false, err
+	}
+
+	now := time.Now()
+	if lastUsed, err := time.Parse(time.RFC3339, apiKey.LastUsed); err != nil || now.Sub(lastUsed) >= apiKeyTouchInterval {
+		id, _ := strconv.Atoi(apiKey.ID)
+		if err := a.users.TouchAPIKey(id, now); err != nil {
+			// ключ действителен, неудачная запись времени использования не мешает запросу
+			log.Println("error in updating api key last_used:", err)
+		}
+	}
+	return user, apiKey.ReadOnly, nil
+}
+
+type readOnlyContextKey struct{}
+
+// withReadOnly отмечает в контексте, что запрос сделан ключом только для чтения
+func withReadOnly(ctx context.Context, readOnly bool) context.Context {
+	return context.WithValue(ctx, readOnlyContextKey{}, readOnly)
+}
+
+// isReadOnly сообщает, сделан ли запрос ключом только для чтения
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyContextKey{}).(bool)
+	return readOnly
+}
+
+// Write пропускает только запросы с правом записи: им отмечаются все маршруты, которые меняют данные,
+// независимо от метода запроса. Ключ только для чтения получает 403.
+func (a *Api) Write(next http.HandlerFunc) http.HandlerFunc {
+	return a.Auth(func(w http.ResponseWriter, r *http.Request) {
+		if isReadOnly(r.Context()) {
+			RenderApiErrorAndResponse(w, fmt.Errorf("api key is read-only"), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+type apiKeyRequest struct {
+	Name     string `json:"name"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// apiKeyResponse — созданный ключ; сам ключ виден только в этом ответе
+type apiKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// GetAPIKeysHandler возвращает API-ключи текущего пользователя без самих ключей
+// GET http://localhost:7540/api/keys
+func (a *Api) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	userID, _ := strconv.Atoi(user.ID)
+
+	keys, err := a.users.GetAPIKeys(userID)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	resp, err := json.Marshal(map[string][]models.APIKey{"keys": keys})
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// CreateAPIKeyHandler создаёт API-ключ текущего пользователя. Ключ возвращается один раз в поле key.
+// POST http://localhost:7540/api/keys {"name": "ci", "read_only": true}
+func (a *Api) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	_, err := buf.ReadFrom(r.Body)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(ReadingError), http.StatusBadRequest)
+		return
+	}
+	req := apiKeyRequest{}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(UnMarshallingError), http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxAPIKeyNameLength {
+		RenderApiErrorAndResponse(w, fmt.Errorf("name must be from 1 to %d characters", maxAPIKeyNameLength),
+			http.StatusBadRequest)
+		return
+	}
+
+	user, _ := UserFromContext(r.Context())
+	userID, _ := strconv.Atoi(user.ID)
+	key, err := newAPIKey()
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	_, err = a.users.AddAPIKey(models.APIKey{
+		UserID:   userID,
+		Name:     req.Name,
+		Prefix:   key[:apiKeyPrefixLength],
+		KeyHash:  hashAPIKey(key),
+		ReadOnly: req.ReadOnly,
+	})
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	created, err := a.users.GetAPIKeyByHash(hashAPIKey(key))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(apiKeyResponse{APIKey: created, Key: key})
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusCreated)
+	WriteResponse(w, resp)
+}
+
+// DeleteAPIKeyHandler отзывает API-ключ текущего пользователя
+// DELETE http://localhost:7540/api/keys/3
+func (a *Api) DeleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidIdError), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := UserFromContext(r.Context())
+	userID, _ := strconv.Atoi(user.ID)
+	err = a.users.DeleteAPIKey(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RenderApiErrorAndResponse(w, fmt.Errorf("api key not found"), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, []byte("{}"))
+}
@@ -7,9 +7,12 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/wisdomdevil/go_final_project/internal/dateutil"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+	"github.com/wisdomdevil/go_final_project/internal/parser"
 )
 
 const (
@@ -24,17 +27,21 @@ const (
 	InvalidRepeatError  = "invalid repeat value"
 	InternalServerError = "internal server error"
 	ValidatingDateError = "error in validating date"
+	InvalidTzError      = "invalid timezone"
 )
 
-// repeatRulePattern checks if the reapeat rule starts with correct letter
-var repeatRulePattern *regexp.Regexp = regexp.MustCompile(`^([mwd]\s\S.*|y$)`)
+// repeatRulePattern checks if the reapeat rule starts with correct letter or is an iCalendar RRULE
+var repeatRulePattern *regexp.Regexp = regexp.MustCompile(`(?i)^([mwdht]\s\S.*|min\s\S.*|y(\s\S.*)?$|(RRULE:)?FREQ=\S+$)`)
 
 type signinRequest struct {
+	Login    string `json:"login"` // пустой логин — администратор
 	Password string `json:"password"`
 }
 
 type signinResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`         // токен доступа для куки token
+	RefreshToken string `json:"refresh_token"` // токен для получения новой пары через /api/refresh
+	ExpiresAt    string `json:"expires_at"`    // когда истекает токен доступа, RFC 3339
 }
 
 // Обработка ошибок для возврата ошибки в виде json.
@@ -74,14 +81,8 @@ func GetNextDay(w http.ResponseWriter, r *http.Request) {
 
 	//check date
 	//Тут спорно, так и не смог определиться, так-то клиент может какую-нибудь дичь закинуть и вернется ошибка, значит это BadRequest, но ошибка произошла на стороне сервера.
-	_, err := strconv.Atoi(date)
-	if err != nil {
-		log.Println("error:", err)
-		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
-		return
-	}
-	//такая же история что и выше, оставил BadRequest
-	dtParsed, err := time.Parse("20060102", date)
+	// date и now могут быть датой 20060102 или датой со временем 20060102T1504 (для правил h, min, t)
+	dtParsed, err := dateutil.ParseDateOrTimestamp(date)
 	if err != nil {
 		log.Println("error:", err)
 		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
@@ -89,14 +90,7 @@ func GetNextDay(w http.ResponseWriter, r *http.Request) {
 	}
 
 	//check now
-	_, err = strconv.Atoi(now)
-	if err != nil {
-		log.Println("error:", err)
-		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidNowDateError), http.StatusBadRequest)
-		return
-	}
-
-	dtNow, err := time.Parse("20060102", now)
+	dtNow, err := dateutil.ParseDateOrTimestamp(now)
 	if err != nil {
 		err := fmt.Errorf("wrong date: %v", err)
 		log.Println("error:", err)
@@ -115,8 +109,14 @@ func GetNextDay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// необязательный список дат-исключений через запятую
+	var skip []string
+	if s := r.URL.Query().Get("skip"); s != "" {
+		skip = strings.Split(s, ",")
+	}
+
 	log.Println("Before nextDay")
-	nextDay, err := dateutil.NextDate(dtNow, dtParsed, repeat)
+	nextDay, err := dateutil.NextDate(dtNow, dtParsed, repeat, skip...)
 
 	if err != nil {
 		err := fmt.Errorf("wrong repeat value")
@@ -129,6 +129,164 @@ func GetNextDay(w http.ResponseWriter, r *http.Request) {
 	WriteResponse(w, []byte(nextDay)) // w.Write([]byte(nextDay))
 }
 
+const (
+	defaultNextDatesCount = 10
+	maxNextDatesCount     = 100
+)
+
+// GetNextDates возвращает JSON-массив следующих дат по правилу повтора
+// http://localhost:7540/api/nextdates?date=20240126&repeat=w+1,3&count=10
+// Параметры now, tz, count, to (последняя дата диапазона) и skip необязательны.
+// Без now отсчёт идёт от сегодняшней даты в поясе tz (IANA), по умолчанию — в поясе сервера.
+func GetNextDates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	loc := time.Local
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			log.Println("error:", err)
+			RenderApiErrorAndResponse(w, fmt.Errorf(InvalidTzError), http.StatusBadRequest)
+			return
+		}
+	}
+
+	dtParsed, err := dateutil.ParseDateOrTimestamp(query.Get("date"))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
+		return
+	}
+
+	dtNow := dateutil.Today(loc)
+	if dateutil.IsSubDayRepeat(query.Get("repeat")) {
+		// правила h, min и t по умолчанию считаются от текущего момента
+		dtNow = dateutil.Now(loc)
+	}
+	if now := query.Get("now"); now != "" {
+		dtNow, err = dateutil.ParseDateOrTimestamp(now)
+		if err != nil {
+			log.Println("error:", err)
+			RenderApiErrorAndResponse(w, fmt.Errorf(InvalidNowDateError), http.StatusBadRequest)
+			return
+		}
+	}
+
+	count := defaultNextDatesCount
+	if c := query.Get("count"); c != "" {
+		count, err = strconv.Atoi(c)
+		if err != nil || count < 1 || count > maxNextDatesCount {
+			RenderApiErrorAndResponse(w, fmt.Errorf("invalid count"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var dtTo time.Time
+	if to := query.Get("to"); to != "" {
+		dtTo, err = dateutil.ParseDateOrTimestamp(to)
+		if err != nil {
+			log.Println("error:", err)
+			RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
+			return
+		}
+		// при заданном диапазоне count ограничивает только размер ответа
+		if query.Get("count") == "" {
+			count = maxNextDatesCount
+		}
+	}
+
+	repeat := query.Get("repeat")
+	if !repeatRulePattern.MatchString(repeat) {
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidRepeatError), http.StatusBadRequest)
+		return
+	}
+
+	var skip []string
+	if s := query.Get("skip"); s != "" {
+		skip = strings.Split(s, ",")
+	}
+
+	dates, err := dateutil.NextDates(dtNow, dtParsed, repeat, count, dtTo, skip...)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidRepeatError), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := json.Marshal(dates)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// repeatDescription — ответ ручки проверки правила повторения
+type repeatDescription struct {
+	Repeat     string `json:"repeat"`
+	RepeatText string `json:"repeat_text"`
+}
+
+// ValidateRepeat проверяет правило повторения и возвращает его описание
+// http://localhost:7540/api/repeat/validate?repeat=m+-1,15+2,8
+// Язык описания выбирается по заголовку Accept-Language (ru или en).
+func ValidateRepeat(w http.ResponseWriter, r *http.Request) {
+	repeat := r.URL.Query().Get("repeat")
+
+	text, err := dateutil.DescribeRepeat(repeat, languageFromRequest(r), 0)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf("%s: %v", InvalidRepeatError, err), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := json.Marshal(repeatDescription{Repeat: repeat, RepeatText: text})
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// languageFromRequest выбирает язык описаний по заголовку Accept-Language, по умолчанию русский
+func languageFromRequest(r *http.Request) string {
+	lang, bestQ := parser.LangRu, -1.0
+	for _, item := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if primary != parser.LangRu && primary != parser.LangEn {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ {
+			lang, bestQ = primary, q
+		}
+	}
+	return lang
+}
+
+// fillRepeatText заполняет описания правил повторения у списка задач
+func fillRepeatText(tasks []models.Task, lang string) {
+	for i := range tasks {
+		tasks[i].FillRepeatText(lang)
+	}
+}
+
 // WriteResponse: если происходит ошибка в методе Write, то она фатальная (panic)
 func WriteResponse(w http.ResponseWriter, s []byte) {
 	_, err := w.Write(s)
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// GetTaskHistoryHandler возвращает историю выполнений задачи.
+// История доступна и для уже удалённых задач (например, выполненных одноразовых).
+// http://localhost:7540/api/tasks/257/history
+func (a *Api) GetTaskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidIdError), http.StatusBadRequest)
+		return
+	}
+
+	completions, err := a.tasks(r).GetCompletions(id)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	result := make(map[string][]models.Completion)
+	result["history"] = completions
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/calendar"
+)
+
+// GetHolidaysHandler возвращает праздники календаря рабочих дней
+func (a *Api) GetHolidaysHandler(w http.ResponseWriter, r *http.Request) {
+	result := make(map[string][]calendar.Holiday)
+	result["holidays"] = a.calendar.Holidays()
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// PostHolidayHandler добавляет праздник: /api/holidays?date=20240101&name=Новый год
+func (a *Api) PostHolidayHandler(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if _, err := time.Parse("20060102", date); err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
+		return
+	}
+
+	err := a.calendar.AddHoliday(calendar.Holiday{Date: date, Name: r.URL.Query().Get("name")})
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, []byte("{}"))
+}
+
+// DeleteHolidayHandler удаляет праздник: /api/holidays?date=20240101
+func (a *Api) DeleteHolidayHandler(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if _, err := time.Parse("20060102", date); err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidDateError), http.StatusBadRequest)
+		return
+	}
+
+	err := a.calendar.RemoveHoliday(date)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, []byte("{}"))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NewRouter собирает роутер приложения со всеми маршрутами API.
+// webDir — каталог со статикой фронтенда; если он пустой, статика не раздаётся (удобно в тестах с httptest).
+func NewRouter(api *Api, webDir string) *chi.Mux {
+	r := chi.NewRouter()
+	if webDir != "" {
+		r.Handle("/*", http.FileServer(http.Dir(webDir)))
+	}
+	r.Get("/api/nextdate", GetNextDay)
+	r.Get("/api/nextdates", GetNextDates)         // несколько следующих дат: ?date=..&repeat=..&count=..
+	r.Get("/api/repeat/validate", ValidateRepeat) // проверка и описание правила: ?repeat=..
+
+	// api, которое ожидается в этом задании
+	// 1. POST /api/task создает таск
+	// 2. GET /api/task возвращает ошибку
+	// 3. GET /api/tasks возвращает набор тасков без фильтрации
+	// 4. GET /api/tasks?search=... возвращает набор тасков с фильрацией по параметру search
+	// 5. GET /api/tasks/{id} возвращает таск по id
+	// маршруты, которые меняют данные, отмечены Write: ключ только для чтения на них не пропускается
+	r.Delete("/api/task", api.Write(api.DeleteTaskHandler))
+	r.Put("/api/task", api.Write(api.PutTaskHandler))
+	r.Post("/api/task", api.Write(api.PostTaskHandler))
+	r.Get("/api/task", api.Auth(api.GetTaskHandler))
+	r.Get("/api/tasks", api.Auth(api.GetTasksHandler))                    // search
+	r.Get("/api/tasks/{id}", api.Auth(api.GetTaskByIdHandler))            // http://localhost:7540/api/tasks/257
+	r.Get("/api/tasks/{id}/history", api.Auth(api.GetTaskHistoryHandler)) // история выполнений задачи
+	r.Post("/api/task/done", api.Write(api.TaskDoneHandler))              // id - это параметр запроса
+	r.Delete("/api/task/done", api.Write(api.TaskDoneHandler))
+	r.Post("/api/task/skip", api.Write(api.TaskSkipHandler))   // добавить дату-исключение: ?id=..&date=..
+	r.Delete("/api/task/skip", api.Write(api.TaskSkipHandler)) // удалить дату-исключение: ?id=..&date=..
+	r.Post("/api/task/undo", api.Write(api.UndoTaskHandler))   // отмена выполнения или удаления: ?id=..&token=..
+
+	// корзина: GET возвращает удалённые задачи, POST /restore возвращает задачу ?id=.., DELETE удаляет окончательно ?id=..
+	r.Get("/api/trash", api.Auth(api.GetTrashHandler))
+	r.Post("/api/trash/restore", api.Write(api.RestoreTaskHandler))
+	r.Delete("/api/trash", api.Write(api.PurgeTaskHandler))
+
+	// календарь праздников: GET возвращает список, POST добавляет ?date=..&name=.., DELETE удаляет ?date=..
+	r.Get("/api/holidays", api.Auth(api.GetHolidaysHandler))
+	r.Post("/api/holidays", api.Admin(api.PostHolidayHandler)) // календарь общий для всех пользователей
+	r.Delete("/api/holidays", api.Admin(api.DeleteHolidayHandler))
+
+	// пользователи
+	r.Post("/api/signup", api.SignupHandler)                // регистрация: {"login": .., "password": ..}
+	r.Post("/api/users", api.Admin(api.CreateUserHandler))  // создание пользователя или администратора
+	r.Get("/api/user", api.Auth(api.GetCurrentUserHandler)) // текущий пользователь
+
+	// личные API-ключи текущего пользователя; ключ передаётся в заголовке Authorization: Bearer.
+	// Ключ только для чтения не создаёт и не отзывает ключи, иначе он мог бы выпустить себе ключ с записью.
+	r.Get("/api/keys", api.Auth(api.GetAPIKeysHandler))
+	r.Post("/api/keys", api.Write(api.CreateAPIKeyHandler))        // {"name": .., "read_only": true}
+	r.Delete("/api/keys/{id}", api.Write(api.DeleteAPIKeyHandler)) // отзыв ключа
+
+	r.Post("/api/signin", api.SigninHandler)
+	r.Post("/api/refresh", api.RefreshHandler) // новая пара токенов по refresh_token
+	r.Post("/api/signout", api.SignoutHandler) // отзыв токенов
+
+	r.Get("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return r
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshHandler выдаёт новую пару токенов по токену обновления. Использованный токен обновления отзывается.
+// POST http://localhost:7540/api/refresh {"refresh_token": "..."}
+func (a *Api) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	_, err := buf.ReadFrom(r.Body)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(ReadingError), http.StatusBadRequest)
+		return
+	}
+	req := refreshRequest{}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(UnMarshallingError), http.StatusBadRequest)
+		return
+	}
+
+	claims, key, err := a.parseToken(req.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf("invalid token"), http.StatusUnauthorized)
+		return
+	}
+	user, err := a.userFromClaims(claims, key)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf("invalid token"), http.StatusUnauthorized)
+		return
+	}
+	// проверка в parseToken не защищает от двух одновременных запросов с одним токеном,
+	// поэтому токен обновления принимается, только если этот запрос сам его отозвал
+	err = a.revokeToken(claims)
+	if errors.Is(err, repo.ErrTokenRevoked) {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf("invalid token"), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.issueTokens(user)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	respBody, err := json.Marshal(response)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, respBody)
+}
+
+// SignoutHandler отзывает токен доступа из куки token и токен обновления из тела запроса, если он передан,
+// и удаляет куку. Нужен хотя бы один действующий токен.
+// POST http://localhost:7540/api/signout {"refresh_token": "..."}
+func (a *Api) SignoutHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	_, err := buf.ReadFrom(r.Body)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(ReadingError), http.StatusBadRequest)
+		return
+	}
+	req := refreshRequest{}
+	if buf.Len() > 0 {
+		if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+			RenderApiErrorAndResponse(w, fmt.Errorf(UnMarshallingError), http.StatusBadRequest)
+			return
+		}
+	}
+
+	revoked := 0
+	revoke := func(raw, kind string) error {
+		claims, _, err := a.parseToken(raw, kind)
+		if err != nil {
+			// недействительный токен отзывать не нужно
+			return nil
+		}
+		err = a.revokeToken(claims)
+		if errors.Is(err, repo.ErrTokenRevoked) {
+			// токен отозвал параллельный запрос
+			return nil
+		}
+		if err == nil {
+			revoked++
+		}
+		return err
+	}
+
+	if cookie, err := r.Cookie("token"); err == nil {
+		err = revoke(cookie.Value, tokenTypeAccess)
+		if err != nil {
+			log.Println("error:", err)
+			RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		if err := revoke(req.RefreshToken, tokenTypeRefresh); err != nil {
+			log.Println("error:", err)
+			RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+	if revoked == 0 {
+		RenderApiErrorAndResponse(w, fmt.Errorf("invalid token"), http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: "", Path: "/", MaxAge: -1})
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, []byte("{}"))
+}
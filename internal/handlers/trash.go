@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// GetTrashHandler возвращает задачи из корзины
+// http://localhost:7540/api/trash
+func (a *Api) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	deletedTasks, err := a.tasks(r).GetDeletedTasks()
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fillRepeatText(deletedTasks, languageFromRequest(r))
+
+	result := make(map[string][]models.Task)
+	result["tasks"] = deletedTasks
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// RestoreTaskHandler возвращает задачу из корзины
+// http://localhost:7540/api/trash/restore?id=257
+func (a *Api) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	a.trashAction(w, r, a.tasks(r).RestoreTask)
+}
+
+// PurgeTaskHandler окончательно удаляет задачу из корзины
+// http://localhost:7540/api/trash?id=257
+func (a *Api) PurgeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	a.trashAction(w, r, a.tasks(r).PurgeDeletedTask)
+}
+
+// trashAction выполняет действие над задачей из корзины по id из параметра запроса
+func (a *Api) trashAction(w http.ResponseWriter, r *http.Request, action func(id int) error) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidIdError), http.StatusBadRequest)
+		return
+	}
+
+	err = action(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidIdError), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, []byte("{}"))
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+)
+
+// Заголовки ответа /api/task/done и DELETE /api/task с токеном отмены.
+// Тело ответа остаётся пустым JSON, как и раньше.
+const (
+	UndoTokenHeader   = "X-Undo-Token"
+	UndoExpiresHeader = "X-Undo-Expires"
+)
+
+// snapshotForUndo сохраняет состояние задачи перед выполнением или удалением и выставляет
+// заголовки с токеном отмены. Возвращает false, если ответ с ошибкой уже отправлен.
+func (a *Api) snapshotForUndo(w http.ResponseWriter, r *http.Request, id int) bool {
+	token, expires, err := a.tasks(r).SnapshotTask(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// задачи нет, отменять нечего; ошибку вернёт сама операция
+		return true
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return false
+	}
+	w.Header().Set(UndoTokenHeader, token)
+	w.Header().Set(UndoExpiresHeader, expires.Format(time.RFC3339))
+	return true
+}
+
+// UndoTaskHandler отменяет последнее выполнение или удаление задачи и возвращает восстановленную задачу
+// http://localhost:7540/api/task/undo?id=257&token=...
+func (a *Api) UndoTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InvalidIdError), http.StatusBadRequest)
+		return
+	}
+
+	err = a.tasks(r).UndoTask(id, r.URL.Query().Get("token"))
+	if errors.Is(err, repo.ErrUndoTokenInvalid) {
+		RenderApiErrorAndResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	a.GetTask(w, r, id)
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
+	"github.com/wisdomdevil/go_final_project/internal/config"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+// AdminLogin — логин администратора, его пароль задаётся переменной TODO_PASSWORD
+const AdminLogin = "admin"
+
+// Допустимая длина пароля нового пользователя; bcrypt учитывает только первые 72 байта
+const (
+	minPasswordLength = 6
+	maxPasswordLength = 72
+)
+
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]{1,64}$`)
+
+type userContextKey struct{}
+
+// withUser кладёт пользователя запроса в контекст
+func withUser(ctx context.Context, user models.User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext возвращает пользователя, которого Auth положил в контекст запроса
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(models.User)
+	return user, ok
+}
+
+// tasks возвращает хранилище задач пользователя запроса
+func (a *Api) tasks(r *http.Request) repo.TaskStore {
+	user, _ := UserFromContext(r.Context())
+	id, err := strconv.Atoi(user.ID)
+	if err != nil || id <= 0 {
+		// запрос без пользователя не должен видеть чужие задачи
+		id = -1
+	}
+	return a.repo.ForUser(id)
+}
+
+// newPasswordHasher создаёт хэшер паролей; хэши старого формата проверяются с прежним зашитым ключом
+func newPasswordHasher() auth.PasswordHasher {
+	return auth.NewBcryptHasher(auth.LegacySecretKey)
+}
+
+// dummyPasswordHash возвращает хэш случайного пароля, посчитанный тем же хэшером, что и хэши пользователей,
+// поэтому его проверка занимает столько же времени. Хэш считается при первом входе с неизвестным логином.
+func (a *Api) dummyPasswordHash() string {
+	a.dummyOnce.Do(func() {
+		password := make([]byte, 32)
+		_, err := rand.Read(password)
+		if err == nil {
+			a.dummyHash, err = a.hasher.Hash(hex.EncodeToString(password))
+		}
+		if err != nil {
+			log.Println("error in hashing dummy password:", err)
+		}
+	})
+	return a.dummyHash
+}
+
+// BootstrapAdmin создаёт администратора, если его нет, и задаёт ему пароль из конфигурации.
+// Хэш пересчитывается, только если пароль сменился или хэш устарел.
+func BootstrapAdmin(users repo.UserStore, cfg *config.Config) error {
+	hasher := newPasswordHasher()
+
+	admin, err := users.GetUserByLogin(AdminLogin)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		if ok, rehash := hasher.Verify(cfg.AppPassword, admin.PasswordHash); ok && !rehash {
+			return nil
+		}
+	}
+
+	hash, err := hasher.Hash(cfg.AppPassword)
+	if err != nil {
+		return err
+	}
+	if admin.ID == "" {
+		_, err = users.AddUser(models.User{Login: AdminLogin, PasswordHash: hash, IsAdmin: true})
+		return err
+	}
+	id, _ := strconv.Atoi(admin.ID)
+	return users.UpdateUserPassword(id, hash)
+}
+
+// rehashPassword сохраняет новый хэш пароля пользователя
+func (a *Api) rehashPassword(user *models.User, password string) error {
+	hash, err := a.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	id, _ := strconv.Atoi(user.ID)
+	if err := a.users.UpdateUserPassword(id, hash); err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return nil
+}
+
+// Admin пропускает только запросы администратора с правом записи; ставится после Auth
+func (a *Api) Admin(next http.HandlerFunc) http.HandlerFunc {
+	return a.Write(func(w http.ResponseWriter, r *http.Request) {
+		if user, _ := UserFromContext(r.Context()); !user.IsAdmin {
+			RenderApiErrorAndResponse(w, fmt.Errorf("admin rights required"), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+type userRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+// SignupHandler регистрирует нового пользователя
+// POST http://localhost:7540/api/signup {"login": "...", "password": "..."}
+func (a *Api) SignupHandler(w http.ResponseWriter, r *http.Request) {
+	a.createUser(w, r, false)
+}
+
+// CreateUserHandler создаёт пользователя, в том числе администратора; доступен администраторам
+// POST http://localhost:7540/api/users {"login": "...", "password": "...", "admin": true}
+func (a *Api) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.createUser(w, r, true)
+}
+
+// GetCurrentUserHandler возвращает пользователя, от имени которого выполняется запрос
+// GET http://localhost:7540/api/user
+func (a *Api) GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+
+	resp, err := json.Marshal(user)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(MarshallingError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	WriteResponse(w, resp)
+}
+
+// createUser создаёт пользователя из тела запроса; allowAdmin разрешает создать администратора
+func (a *Api) createUser(w http.ResponseWriter, r *http.Request, allowAdmin bool) {
+	var buf bytes.Buffer
+
+	_, err := buf.ReadFrom(r.Body)
+	if err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(ReadingError), http.StatusBadRequest)
+		return
+	}
+
+	req := userRequest{}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		RenderApiErrorAndResponse(w, fmt.Errorf(UnMarshallingError), http.StatusBadRequest)
+		return
+	}
+	if !loginPattern.MatchString(req.Login) {
+		RenderApiErrorAndResponse(w, fmt.Errorf("invalid login"), http.StatusBadRequest)
+		return
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		RenderApiErrorAndResponse(w, fmt.Errorf("password must be from %d to %d characters", minPasswordLength, maxPasswordLength),
+			http.StatusBadRequest)
+		return
+	}
+
+	hash, err := a.hasher.Hash(req.Password)
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+	id, err := a.users.AddUser(models.User{
+		Login:        req.Login,
+		PasswordHash: hash,
+		IsAdmin:      allowAdmin && req.Admin,
+	})
+	if errors.Is(err, repo.ErrUserExists) {
+		RenderApiErrorAndResponse(w, err, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println("error:", err)
+		RenderApiErrorAndResponse(w, fmt.Errorf(InternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusCreated)
+	WriteResponse(w, []byte(fmt.Sprintf("{\"id\":%d}", id)))
+}
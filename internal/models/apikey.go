@@ -0,0 +1,14 @@
+package models
+
+// APIKey — личный ключ пользователя для доступа к API из скриптов (таблица api_keys).
+// Сам ключ показывается один раз при создании, в БД хранится только его хэш.
+type APIKey struct {
+	ID        string `json:"id"`
+	UserID    int    `json:"-"` // владелец ключа
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"` // начало ключа, чтобы отличать ключи в списке
+	KeyHash   string `json:"-"`
+	ReadOnly  bool   `json:"read_only"`  // ключ разрешает только чтение
+	CreatedAt string `json:"created_at"` // момент создания в UTC в формате RFC 3339
+	LastUsed  string `json:"last_used"`  // последнее использование; пусто, если ключ не использовался
+}
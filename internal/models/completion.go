@@ -0,0 +1,13 @@
+package models
+
+// Completion — запись о выполнении задачи в истории (таблица task_completions).
+// История хранится и после удаления задачи, чтобы можно было подтвердить выполненные проверки.
+type Completion struct {
+	ID          string `json:"id"`
+	TaskID      string `json:"task_id"`
+	Date        string `json:"date"`           // дата задачи по расписанию в формате 20060102
+	Time        string `json:"time,omitempty"` // время задачи по расписанию в формате 15:04, если было задано
+	CompletedAt string `json:"completed_at"`   // момент выполнения в UTC в формате RFC 3339
+	Note        string `json:"note,omitempty"` // необязательная заметка
+	UserID      int    `json:"-"`              // владелец задачи
+}
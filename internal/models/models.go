@@ -3,17 +3,40 @@ package models
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/wisdomdevil/go_final_project/internal/dateutil"
 )
 
+// Режимы отсчёта следующей даты повторяющейся задачи
+const (
+	AnchorSchedule   = "schedule"   // от даты по расписанию (по умолчанию)
+	AnchorCompletion = "completion" // от момента выполнения задачи
+)
+
+// MaxSkipDates — сколько дат-исключений можно задать задаче; список хранится в столбце skip VARCHAR(1024)
+const MaxSkipDates = 100
+
 type Task struct {
 	ID      string `json:"id"`      // uint   `json:"id"`
 	Date    string `json:"date"`    // дата задачи в формате 20060102
 	Title   string `json:"title"`   // заголовок задачи
 	Comment string `json:"comment"` // комментарий к задаче
 	Repeat  string `json:"repeat"`  // правило повторения
+
+	Remaining int    `json:"remaining,string,omitempty"` // сколько повторений осталось для правила с условием count
+	Skip      string `json:"skip,omitempty"`             // даты-исключения через запятую в формате 20060102
+	RuleDate  string `json:"-"`                          // дата по правилу до переноса shift на рабочий день; пусто, если дата не переносилась
+	Time      string `json:"time,omitempty"`             // время задачи в формате 15:04, необязательно
+	Timezone  string `json:"timezone,omitempty"`         // часовой пояс IANA, например Europe/Moscow; по умолчанию пояс сервера
+	Anchor    string `json:"anchor,omitempty"`           // от чего считать следующую дату: schedule или completion
+	DeletedAt string `json:"deleted_at,omitempty"`       // момент удаления в корзину в UTC (RFC 3339), пусто у активных задач
+	UserID    int    `json:"-"`                          // владелец задачи
+
+	RepeatText string `json:"repeat_text,omitempty"` // описание правила повторения, в БД не хранится
+	Snippet    string `json:"snippet,omitempty"`     // фрагмент текста с подсвеченными словами при поиске, в БД не хранится
 }
 
 // ValidateAndNormalizeDate checks the incoming data and sets the next date of the event.
@@ -22,7 +45,46 @@ func (t *Task) ValidateAndNormalizeDate() error {
 		err := fmt.Errorf("The title field is empty.")
 		return err
 	}
-	now := time.Now().Truncate(24 * time.Hour)
+
+	if t.Repeat != "" {
+		if err := dateutil.ValidateRepeat(t.Repeat); err != nil {
+			return err
+		}
+	}
+
+	if err := t.normalizeRemaining(); err != nil {
+		return err
+	}
+	if err := t.normalizeSkip(); err != nil {
+		return err
+	}
+
+	switch t.Anchor {
+	case "":
+		t.Anchor = AnchorSchedule
+	case AnchorSchedule, AnchorCompletion:
+	default:
+		return fmt.Errorf("The field anchor is wrong")
+	}
+
+	if t.Time != "" {
+		if _, err := time.Parse(TimeTemplate, t.Time); err != nil {
+			return fmt.Errorf("The field time is wrong")
+		}
+	}
+	loc, err := t.Location()
+	if err != nil {
+		return err
+	}
+
+	// правила h, min и t работают со временем, без него отсчёт идёт от текущего момента
+	subDay := dateutil.IsSubDayRepeat(t.Repeat)
+	if subDay && t.Time == "" {
+		t.Time = dateutil.Now(loc).Format(TimeTemplate)
+	}
+
+	// сегодняшняя дата считается в часовом поясе задачи
+	now := dateutil.Today(loc)
 	log.Printf("Today is %v", now)
 
 	if t.Date == "" {
@@ -44,24 +106,33 @@ func (t *Task) ValidateAndNormalizeDate() error {
 		return err
 	}
 
-	dt, err := time.Parse("20060102", t.Date)
+	// следующая дата считается от даты по правилу, если дата задачи была перенесена shift
+	dt, err := t.RuleMoment()
 	if err != nil {
 		return err
 	}
 
-	if now.After(date) { // Если дата меньше сегодняшнего числа
+	if subDay {
+		return t.normalizeSubDay(loc)
+	}
+
+	if now.After(date) || (now.Equal(date) && t.Repeat != "" && t.timePassed(loc)) { // Если дата меньше сегодняшнего числа
 		// если правило повторения не указано или равно пустой строке, подставляется сегодняшнее число
-		if t.Repeat == "" {
-			log.Printf("Repeat rule is empty.")
+		// задача с отсчётом от выполнения просто просрочена и тоже переносится на сегодня
+		if t.Repeat == "" || t.Anchor == AnchorCompletion {
+			log.Printf("Repeat rule is empty or anchored to completion.")
 			t.Date = now.Format("20060102")
+			t.RuleDate = ""
 		} else {
 			log.Printf("Repeat rule is not empty.")
-			nextDate, err := dateutil.NextDate(now, dt, t.Repeat)
+			nextDate, base, err := dateutil.NextDateWithBase(now, dt, t.Repeat, t.SkipDates()...)
 			if err != nil {
 				log.Printf("Error in NextDate function: %v", err)
 				return err
 			}
-			t.Date = nextDate
+			if err := t.SetNextDateWithBase(nextDate, base); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -69,3 +140,229 @@ func (t *Task) ValidateAndNormalizeDate() error {
 	fmt.Println("Error in ValidateAndNormalizeDate:", err)
 	return nil
 }
+
+// normalizeRemaining выставляет остаток повторений по условию count правила повторения.
+// Уже уменьшенный остаток сохраняется, если он не больше count.
+func (t *Task) normalizeRemaining() error {
+	count, err := dateutil.RepeatCount(t.Repeat)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		t.Remaining = 0
+		return nil
+	}
+	if t.Remaining <= 0 || t.Remaining > count {
+		t.Remaining = count
+	}
+	return nil
+}
+
+// normalizeSkip проверяет даты-исключения из запроса, убирает повторы и сортирует их
+func (t *Task) normalizeSkip() error {
+	dates := t.SkipDates()
+	if len(dates) == 0 {
+		return nil
+	}
+	if t.Repeat == "" {
+		return fmt.Errorf("Skip dates are allowed only for repeating tasks.")
+	}
+
+	unique := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		if _, err := time.Parse("20060102", d); err != nil {
+			return fmt.Errorf("The skip date is wrong")
+		}
+		unique[d] = true
+	}
+	if len(unique) > MaxSkipDates {
+		return fmt.Errorf("Too many skip dates, the limit is %d.", MaxSkipDates)
+	}
+
+	dates = dates[:0]
+	for d := range unique {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+	t.Skip = strings.Join(dates, ",")
+	return nil
+}
+
+// SkipDates возвращает список дат-исключений задачи
+func (t *Task) SkipDates() []string {
+	if t.Skip == "" {
+		return nil
+	}
+	return strings.Split(t.Skip, ",")
+}
+
+// AddSkipDate добавляет дату-исключение в формате 20060102 к повторяющейся задаче
+func (t *Task) AddSkipDate(date string) error {
+	if t.Repeat == "" {
+		return fmt.Errorf("Skip dates are allowed only for repeating tasks.")
+	}
+	if _, err := time.Parse("20060102", date); err != nil {
+		return fmt.Errorf("The skip date is wrong")
+	}
+
+	dates := t.SkipDates()
+	for _, d := range dates {
+		if d == date {
+			return nil
+		}
+	}
+	if len(dates) >= MaxSkipDates {
+		return fmt.Errorf("Too many skip dates, the limit is %d.", MaxSkipDates)
+	}
+	dates = append(dates, date)
+	sort.Strings(dates)
+	t.Skip = strings.Join(dates, ",")
+	return nil
+}
+
+// RemoveSkipDate удаляет дату-исключение задачи
+func (t *Task) RemoveSkipDate(date string) {
+	dates := []string{}
+	for _, d := range t.SkipDates() {
+		if d != date {
+			dates = append(dates, d)
+		}
+	}
+	t.Skip = strings.Join(dates, ",")
+}
+
+// FillRepeatText заполняет описание правила повторения на языке lang
+func (t *Task) FillRepeatText(lang string) {
+	t.RepeatText = ""
+	if t.Repeat == "" {
+		return
+	}
+	text, err := dateutil.DescribeRepeat(t.Repeat, lang, t.Remaining, t.SkipDates()...)
+	if err != nil {
+		log.Printf("Can not describe repeat rule %q: %v", t.Repeat, err)
+		return
+	}
+	t.RepeatText = text
+}
+
+// TimeTemplate — формат времени задачи
+const TimeTemplate = "15:04"
+
+// Location возвращает часовой пояс задачи, для пустого поля — часовой пояс сервера
+func (t *Task) Location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("The field timezone is wrong")
+	}
+	return loc, nil
+}
+
+// Today возвращает сегодняшнюю дату в часовом поясе задачи
+func (t *Task) Today() time.Time {
+	loc, err := t.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	return dateutil.Today(loc)
+}
+
+// IsUpcoming определяет, что задача назначена на сегодня или позже в своём часовом поясе
+func (t *Task) IsUpcoming() bool {
+	return t.Date >= t.Today().Format("20060102")
+}
+
+// timePassed определяет, что время задачи на сегодня уже прошло в часовом поясе loc
+func (t *Task) timePassed(loc *time.Location) bool {
+	if t.Time == "" {
+		return false
+	}
+	return time.Now().In(loc).Format(TimeTemplate) > t.Time
+}
+
+// AnchoredToCompletion определяет, что следующая дата считается от момента выполнения задачи
+func (t *Task) AnchoredToCompletion() bool {
+	return t.Anchor == AnchorCompletion
+}
+
+// normalizeSubDay переносит задачу с правилом h, min или t на ближайший момент не раньше текущего
+func (t *Task) normalizeSubDay(loc *time.Location) error {
+	moment, err := t.Moment()
+	if err != nil {
+		return err
+	}
+	now := dateutil.Now(loc)
+	if !moment.Before(now) {
+		return nil
+	}
+
+	// задача с отсчётом от выполнения просто просрочена и переносится на текущий момент
+	if t.Anchor == AnchorCompletion {
+		return t.SetNextDate(now.Format(dateutil.TimestampTemplate))
+	}
+
+	nextDate, err := dateutil.NextDate(now, moment, t.Repeat, t.SkipDates()...)
+	if err != nil {
+		return err
+	}
+	return t.SetNextDate(nextDate)
+}
+
+// Moment возвращает момент, от которого считается следующая дата по правилу:
+// для правил h, min и t — дату вместе со временем задачи, для остальных — только дату.
+// Значение хранится в UTC, часы и минуты совпадают с местными для часового пояса задачи.
+func (t *Task) Moment() (time.Time, error) {
+	if dateutil.IsSubDayRepeat(t.Repeat) && t.Time != "" {
+		return time.Parse(dateutil.DateTemplate+TimeTemplate, t.Date+t.Time)
+	}
+	return time.Parse(dateutil.DateTemplate, t.Date)
+}
+
+// RuleMoment возвращает момент, от которого считается следующая дата по правилу: как Moment,
+// но для даты, перенесённой модификатором shift, — дату по правилу до переноса
+func (t *Task) RuleMoment() (time.Time, error) {
+	if t.RuleDate != "" {
+		return time.Parse(dateutil.DateTemplate, t.RuleDate)
+	}
+	return t.Moment()
+}
+
+// CurrentMoment возвращает текущий момент в часовом поясе задачи в том же виде, что и Moment
+func (t *Task) CurrentMoment() time.Time {
+	if !dateutil.IsSubDayRepeat(t.Repeat) {
+		return t.Today()
+	}
+	loc, err := t.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	return dateutil.Now(loc)
+}
+
+// SetNextDate записывает в задачу результат dateutil.NextDate: дату или дату со временем
+func (t *Task) SetNextDate(next string) error {
+	d, err := dateutil.ParseDateOrTimestamp(next)
+	if err != nil {
+		return err
+	}
+	t.Date = d.Format(dateutil.DateTemplate)
+	t.RuleDate = ""
+	if len(next) == len(dateutil.TimestampTemplate) {
+		t.Time = d.Format(TimeTemplate)
+	}
+	return nil
+}
+
+// SetNextDateWithBase записывает в задачу результат dateutil.NextDateWithBase;
+// дата по правилу сохраняется, только если она отличается от даты задачи
+func (t *Task) SetNextDateWithBase(next string, base string) error {
+	if err := t.SetNextDate(next); err != nil {
+		return err
+	}
+	if base != next {
+		t.RuleDate = base
+	}
+	return nil
+}
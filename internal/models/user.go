@@ -0,0 +1,10 @@
+package models
+
+// User — учётная запись пользователя (таблица users). Каждый пользователь видит только свои задачи.
+type User struct {
+	ID           string `json:"id"`
+	Login        string `json:"login"`
+	PasswordHash string `json:"-"`
+	IsAdmin      bool   `json:"admin"`
+	CreatedAt    string `json:"created_at"` // момент регистрации в UTC в формате RFC 3339
+}
@@ -0,0 +1,389 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Языки описаний правил повторения
+const (
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+var weekdayNamesRu = map[time.Weekday]string{
+	time.Monday:    "понедельник",
+	time.Tuesday:   "вторник",
+	time.Wednesday: "среда",
+	time.Thursday:  "четверг",
+	time.Friday:    "пятница",
+	time.Saturday:  "суббота",
+	time.Sunday:    "воскресенье",
+}
+
+var monthNamesRu = []string{"", "январь", "февраль", "март", "апрель", "май", "июнь",
+	"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь"}
+
+// plural выбирает форму слова для числа n: plural(5, "день", "дня", "дней") == "дней"
+func plural(n int, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	}
+	return many
+}
+
+// every возвращает «every N units» или «каждые N единиц» с учётом числа.
+// ruEvery — «каждый», «каждую» или «каждое» в роде единицы: «каждую неделю», «каждую 21 неделю».
+func every(lang string, n int, en, enMany, ruEvery, ruOne, ruFew, ruMany string) string {
+	if lang == LangEn {
+		if n == 1 {
+			return "every " + en
+		}
+		return fmt.Sprintf("every %d %s", n, enMany)
+	}
+	if n == 1 {
+		return ruEvery + " " + ruOne
+	}
+	word := plural(n, ruOne, ruFew, ruMany)
+	if word == ruOne {
+		return fmt.Sprintf("%s %d %s", ruEvery, n, word)
+	}
+	return fmt.Sprintf("каждые %d %s", n, word)
+}
+
+// weekdayName возвращает название дня недели на нужном языке
+func weekdayName(lang string, wd time.Weekday) string {
+	if lang == LangEn {
+		return wd.String()
+	}
+	return weekdayNamesRu[wd]
+}
+
+// monthList возвращает список месяцев через запятую
+func monthList(lang string, months []int) string {
+	names := []string{}
+	for _, m := range months {
+		if lang == LangEn {
+			names = append(names, time.Month(m).String())
+		} else {
+			names = append(names, monthNamesRu[m])
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// monthDayName описывает число месяца, -1 и -2 — последний и предпоследний день
+func monthDayName(lang string, day int) string {
+	switch {
+	case day == -1 && lang == LangEn:
+		return "last day"
+	case day == -1:
+		return "последний день"
+	case day == -2 && lang == LangEn:
+		return "day before last"
+	case day == -2:
+		return "предпоследний день"
+	case day < 0 && lang == LangEn:
+		return fmt.Sprintf("%s day from the end", ordinalEn(-day))
+	case day < 0:
+		return fmt.Sprintf("%d-й день с конца", -day)
+	}
+	return fmt.Sprint(day)
+}
+
+// nthWeekdayName описывает n-й день недели месяца: «2nd Tuesday» или «2-й вторник»
+func nthWeekdayName(lang string, wd nthWeekday) string {
+	name := weekdayName(lang, wd.weekday)
+	if lang == LangEn {
+		switch {
+		case wd.n == 0:
+			return name
+		case wd.n == -1:
+			return "last " + name
+		case wd.n < 0:
+			return fmt.Sprintf("%s %s from the end", ordinalEn(-wd.n), name)
+		}
+		return ordinalEn(wd.n) + " " + name
+	}
+
+	// род дня недели: среда, пятница, суббота — женский, воскресенье — средний
+	ending, last, beforeLast := "й", "последний", "предпоследний"
+	switch wd.weekday {
+	case time.Wednesday, time.Friday, time.Saturday:
+		ending, last, beforeLast = "я", "последняя", "предпоследняя"
+	case time.Sunday:
+		ending, last, beforeLast = "е", "последнее", "предпоследнее"
+	}
+	switch {
+	case wd.n == 0:
+		return name
+	case wd.n == -1:
+		return last + " " + name
+	case wd.n == -2:
+		return beforeLast + " " + name
+	case wd.n < 0:
+		return fmt.Sprintf("%d-%s с конца %s", -wd.n, ending, name)
+	}
+	return fmt.Sprintf("%d-%s %s", wd.n, ending, name)
+}
+
+// ordinalEn возвращает английское порядковое числительное: 1st, 2nd, 3rd, 4th
+func ordinalEn(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// formatDate форматирует дату для описания
+func formatDate(t time.Time) string {
+	return t.Format("02.01.2006")
+}
+
+// --------------------------------------------------------
+
+// Describe возвращает описание правила d
+func (dr *DRepeat) Describe(lang string) string {
+	if dr.business {
+		return every(lang, dr.num, "working day", "working days", "каждый", "рабочий день", "рабочих дня", "рабочих дней")
+	}
+	return every(lang, dr.num, "day", "days", "каждый", "день", "дня", "дней")
+}
+
+// Describe возвращает описание правила y
+func (yr *YRepeat) Describe(lang string) string {
+	if lang == LangEn {
+		return "every year"
+	}
+	return "каждый год"
+}
+
+// Describe возвращает описание правила w
+func (wr *WRepeat) Describe(lang string) string {
+	nums := append([]int{}, wr.nums...)
+	sort.Ints(nums)
+
+	days := []string{}
+	for _, n := range nums {
+		days = append(days, weekdayName(lang, time.Weekday(n%7)))
+	}
+	if lang == LangEn {
+		return "every week on " + strings.Join(days, ", ")
+	}
+	return "каждую неделю: " + strings.Join(days, ", ")
+}
+
+// Describe возвращает описание правила m
+func (mr *MRepeat) Describe(lang string) string {
+	days := []string{}
+	for _, d := range mr.ruleDays {
+		days = append(days, monthDayName(lang, d))
+	}
+
+	if lang == LangEn {
+		result := "every month on: " + strings.Join(days, ", ")
+		if mr.hasMonths() {
+			result += " in " + monthList(lang, mr.mMonths)
+		}
+		return result
+	}
+	result := "каждый месяц, числа: " + strings.Join(days, ", ")
+	if mr.hasMonths() {
+		result += "; месяцы: " + monthList(lang, mr.mMonths)
+	}
+	return result
+}
+
+// Describe возвращает описание правила m с днями недели
+func (mr *MWeekdayRepeat) Describe(lang string) string {
+	days := []string{}
+	for _, wd := range mr.weekdays {
+		days = append(days, nthWeekdayName(lang, wd))
+	}
+
+	if lang == LangEn {
+		result := "every month on the " + strings.Join(days, ", ")
+		if len(mr.mMonths) > 0 {
+			result += " in " + monthList(lang, mr.mMonths)
+		}
+		return result
+	}
+	result := "каждый месяц: " + strings.Join(days, ", ")
+	if len(mr.mMonths) > 0 {
+		result += "; месяцы: " + monthList(lang, mr.mMonths)
+	}
+	return result
+}
+
+// withUntil дополняет описание датой окончания
+func withUntil(lang string, text string, until time.Time) string {
+	if lang == LangEn {
+		return text + ", until " + formatDate(until)
+	}
+	return text + ", до " + formatDate(until)
+}
+
+// withCount дополняет описание числом повторений
+func withCount(lang string, text string, count int) string {
+	if lang == LangEn {
+		if count == 1 {
+			return text + ", once"
+		}
+		return fmt.Sprintf("%s, %d times", text, count)
+	}
+	return fmt.Sprintf("%s, %d %s", text, count, plural(count, "раз", "раза", "раз"))
+}
+
+// withRemaining дополняет описание остатком повторений из общего числа count
+func withRemaining(lang string, text string, remaining, count int) string {
+	if lang == LangEn {
+		return fmt.Sprintf("%s, %d of %d times left", text, remaining, count)
+	}
+	return fmt.Sprintf("%s, %s %d из %d раз", text, plural(remaining, "остался", "осталось", "осталось"), remaining, count)
+}
+
+// Describe возвращает описание правила с интервалом
+func (ir *IntervalRepeat) Describe(lang string) string {
+	if lang == LangEn {
+		period := "years"
+		switch ir.unit {
+		case intervalWeek:
+			period = "weeks"
+		case intervalMonth:
+			period = "months"
+		}
+		return fmt.Sprintf("%s, every %d %s", ir.rule.Describe(lang), ir.interval, period)
+	}
+
+	var period string
+	switch ir.unit {
+	case intervalWeek:
+		period = plural(ir.interval, "неделю", "недели", "недель")
+	case intervalMonth:
+		period = plural(ir.interval, "месяц", "месяца", "месяцев")
+	default:
+		period = plural(ir.interval, "год", "года", "лет")
+	}
+	return fmt.Sprintf("%s, раз в %d %s", ir.rule.Describe(lang), ir.interval, period)
+}
+
+// Describe возвращает описание правила с датой окончания
+func (ur *UntilRepeat) Describe(lang string) string {
+	return withUntil(lang, ur.rule.Describe(lang), ur.until)
+}
+
+// Describe возвращает описание правила с числом повторений
+func (cr *CountRepeat) Describe(lang string) string {
+	if cr.remaining > 0 && cr.remaining < cr.count {
+		return withRemaining(lang, cr.rule.Describe(lang), cr.remaining, cr.count)
+	}
+	return withCount(lang, cr.rule.Describe(lang), cr.count)
+}
+
+// Describe возвращает описание правила с датами-исключениями
+func (sr *SkipRepeat) Describe(lang string) string {
+	dates := []string{}
+	for d := range sr.skip {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+	for i, d := range dates {
+		if t, err := time.Parse("20060102", d); err == nil {
+			dates[i] = formatDate(t)
+		}
+	}
+
+	if lang == LangEn {
+		return sr.rule.Describe(lang) + ", except " + strings.Join(dates, ", ")
+	}
+	return sr.rule.Describe(lang) + ", кроме " + strings.Join(dates, ", ")
+}
+
+// Describe возвращает описание правила с переносом на рабочий день
+func (sr *ShiftRepeat) Describe(lang string) string {
+	if lang == LangEn {
+		return sr.rule.Describe(lang) + ", moved to the next working day if it falls on a day off"
+	}
+	return sr.rule.Describe(lang) + ", с переносом на ближайший рабочий день"
+}
+
+// Describe возвращает описание правила RRULE
+func (rr *RRuleRepeat) Describe(lang string) string {
+	var result string
+	switch rr.freq {
+	case freqDaily:
+		result = every(lang, rr.interval, "day", "days", "каждый", "день", "дня", "дней")
+	case freqWeekly:
+		result = every(lang, rr.interval, "week", "weeks", "каждую", "неделю", "недели", "недель")
+	case freqMonthly:
+		result = every(lang, rr.interval, "month", "months", "каждый", "месяц", "месяца", "месяцев")
+	default:
+		result = every(lang, rr.interval, "year", "years", "каждый", "год", "года", "лет")
+	}
+
+	parts := []string{}
+	if len(rr.byDay) > 0 {
+		days := []string{}
+		for _, wd := range rr.byDay {
+			days = append(days, nthWeekdayName(lang, wd))
+		}
+		parts = append(parts, strings.Join(days, ", "))
+	}
+	if len(rr.byMonthDay) > 0 {
+		days := []string{}
+		for _, d := range rr.byMonthDay {
+			if lang == LangEn && d > 0 {
+				// «day 15», но «last day» и «3rd day from the end» без повтора слова
+				days = append(days, "day "+monthDayName(lang, d))
+				continue
+			}
+			days = append(days, monthDayName(lang, d))
+		}
+		if lang == LangEn {
+			parts = append(parts, strings.Join(days, ", "))
+		} else {
+			parts = append(parts, "числа: "+strings.Join(days, ", "))
+		}
+	}
+	if len(rr.byMonth) > 0 {
+		if lang == LangEn {
+			parts = append(parts, "in "+monthList(lang, rr.byMonth))
+		} else {
+			parts = append(parts, "месяцы: "+monthList(lang, rr.byMonth))
+		}
+	}
+	if len(parts) > 0 {
+		if lang == LangEn {
+			result += " on " + strings.Join(parts, " ")
+		} else {
+			result += ": " + strings.Join(parts, "; ")
+		}
+	}
+
+	if rr.remaining > 0 && rr.remaining < rr.count {
+		result = withRemaining(lang, result, rr.remaining, rr.count)
+	} else if rr.count > 0 {
+		result = withCount(lang, result, rr.count)
+	}
+	if !rr.until.IsZero() {
+		result = withUntil(lang, result, rr.until)
+	}
+	return result
+}
@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -11,7 +13,8 @@ import (
 
 // DRepeat хранит число правила d
 type DRepeat struct {
-	num int
+	num      int
+	business bool // считать только рабочие дни
 }
 
 const (
@@ -21,15 +24,27 @@ const (
 )
 
 // ParseDRepeat заполняет структуру DRepeat
-// Сигнатура правила d: d <число> — задача переносится на указанное число дней.
+// Сигнатура правила d: d <число> [bd] — задача переносится на указанное число дней.
+// С модификатором bd считаются только рабочие дни (без выходных и праздников календаря).
 // Максимально допустимое число равно 400
 func ParseDRepeat(rule []string) (*DRepeat, error) {
+	if len(rule) < 2 || len(rule) > 3 {
+		return nil, fmt.Errorf("error in d rule")
+	}
+	business := false
+	if len(rule) == 3 {
+		if rule[2] != businessKeyword {
+			return nil, fmt.Errorf("error in checking modifier in repeat rule 'd', got '%s'", rule[2])
+		}
+		business = true
+	}
+
 	next, err := strconv.Atoi(rule[1])
 	if err != nil {
 		return nil, fmt.Errorf("error in checking days in repeat rule, got '%s'", rule[1])
 	}
 	if next > minDays && next <= maxDays {
-		return &DRepeat{num: next}, nil
+		return &DRepeat{num: next, business: business}, nil
 	}
 	return nil, fmt.Errorf("expected number of days less than 400, got '%s'", rule[1])
 }
@@ -39,7 +54,11 @@ func ParseDRepeat(rule []string) (*DRepeat, error) {
 func (dr *DRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
 	result := date
 	for {
-		result = result.AddDate(0, 0, dr.num)
+		if dr.business {
+			result = addWorkingDays(result, dr.num)
+		} else {
+			result = result.AddDate(0, 0, dr.num)
+		}
 		if result.After(now) {
 			return result, nil
 		}
@@ -131,8 +150,9 @@ func (wr *WRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error)
 
 // MRepeat хранит список дней и список месяцев правила m
 type MRepeat struct {
-	mDays   []int
-	mMonths []int
+	mDays    []int
+	mMonths  []int
+	ruleDays []int // дни из правила как есть, без преобразования -1 и -2, нужны для описания
 }
 
 // hasMonths определяет, есть ли в правиле m месяцы
@@ -214,7 +234,12 @@ func ParseMRepeat(rule []string, now time.Time, date time.Time) (*MRepeat, error
 			months = append(months, num)
 		}
 	}
-	return &MRepeat{mDays: days, mMonths: months}, nil
+	ruleDays := []int{}
+	for _, day := range daysInRule {
+		num, _ := strconv.Atoi(day)
+		ruleDays = append(ruleDays, num)
+	}
+	return &MRepeat{mDays: days, mMonths: months, ruleDays: ruleDays}, nil
 }
 
 // GetNextDate вычисляет следующую дату по правилу m
@@ -261,10 +286,507 @@ func (mr *MRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error)
 	return time.Time{}, fmt.Errorf("Error in checking days and months in 'm' repeat rule")
 }
 
+// --------------------------------------------------------
+
+// weekdayNames сопоставляет сокращённые названия дней недели с time.Weekday
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// nthWeekdayPattern описывает элемент правила вида 2tue или -1fri
+var nthWeekdayPattern = regexp.MustCompile(`^(-?[1-5])(mon|tue|wed|thu|fri|sat|sun)$`)
+
+// maxMonthsToSearch ограничивает поиск подходящего месяца (например, 5-го понедельника февраля)
+const maxMonthsToSearch = 12 * 30
+
+// nthWeekday хранит порядковый номер дня недели в месяце и сам день недели
+type nthWeekday struct {
+	n       int // 1..5 считается от начала месяца, -1..-5 от конца
+	weekday time.Weekday
+}
+
+// MWeekdayRepeat хранит список дней недели с номерами и список месяцев правила m
+// Сигнатура: m <через запятую [-]номер+день недели> [через запятую от 1 до 12]
+// Например, m 2tue — второй вторник каждого месяца, m -1fri 3,6,9,12 — последняя пятница квартала.
+type MWeekdayRepeat struct {
+	weekdays []nthWeekday
+	mMonths  []int
+}
+
+// isMWeekdayRule определяет, задаёт ли вторая часть правила m дни недели, а не числа месяца
+func isMWeekdayRule(rule []string) bool {
+	if len(rule) < 2 {
+		return false
+	}
+	for _, part := range strings.Split(rule[1], ",") {
+		if !nthWeekdayPattern.MatchString(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMWeekdayRepeat заполняет структуру MWeekdayRepeat
+func ParseMWeekdayRepeat(rule []string) (*MWeekdayRepeat, error) {
+	if len(rule) == 1 || len(rule) > 3 {
+		return nil, fmt.Errorf("Error in m rule.")
+	}
+
+	weekdays := []nthWeekday{}
+	for _, part := range strings.Split(rule[1], ",") {
+		match := nthWeekdayPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Errorf("Error in checking weekdays in repeat rule 'm', got '%s'", part)
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("Error in checking weekdays in repeat rule 'm', got '%s'", part)
+		}
+		weekdays = append(weekdays, nthWeekday{n: n, weekday: weekdayNames[match[2]]})
+	}
+
+	months := []int{}
+	if len(rule) == 3 {
+		for _, month := range strings.Split(rule[2], ",") {
+			num, err := strconv.Atoi(month)
+			if err != nil || num < 1 || num > 12 {
+				return nil, fmt.Errorf("Error in checking months in repeat rule 'm', got '%s'", month)
+			}
+			months = append(months, num)
+		}
+	}
+	return &MWeekdayRepeat{weekdays: weekdays, mMonths: months}, nil
+}
+
+// GetNextDate вычисляет следующую дату по правилу m с днями недели
+// Перебираются месяцы начиная с текущего, в каждом ищется ближайший подходящий день недели
+func (mr *MWeekdayRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	startdate := startDateForMWrule(now, date)
+
+	for i := 0; i < maxMonthsToSearch; i++ {
+		month := Date(startdate.Year(), int(startdate.Month())+i, 1)
+		if !mr.monthAllowed(int(month.Month())) {
+			continue
+		}
+
+		var nextDay time.Time
+		for _, wd := range mr.weekdays {
+			day, ok := nthWeekdayOfMonth(month.Year(), int(month.Month()), wd)
+			if !ok || !day.After(startdate) {
+				continue
+			}
+			if nextDay.IsZero() || day.Before(nextDay) {
+				nextDay = day
+			}
+		}
+		if !nextDay.IsZero() {
+			return nextDay, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Error in checking weekdays and months in 'm' repeat rule")
+}
+
+// monthAllowed проверяет, входит ли месяц в список месяцев правила (пустой список — любой месяц)
+func (mr *MWeekdayRepeat) monthAllowed(month int) bool {
+	if len(mr.mMonths) == 0 {
+		return true
+	}
+	for _, m := range mr.mMonths {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// nthWeekdayOfMonth возвращает n-й (или n-й с конца) день недели месяца.
+// Второе значение равно false, если такого дня в месяце нет (например, 5-й вторник).
+func nthWeekdayOfMonth(year, month int, wd nthWeekday) (time.Time, bool) {
+	if wd.n > 0 {
+		first := Date(year, month, 1)
+		offset := (int(wd.weekday) - int(first.Weekday()) + 7) % 7
+		day := 1 + offset + (wd.n-1)*7
+		if day > Date(year, month+1, 0).Day() {
+			return time.Time{}, false
+		}
+		return Date(year, month, day), true
+	}
+
+	last := Date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(wd.weekday) + 7) % 7
+	day := last.Day() - offset + (wd.n+1)*7
+	if day < 1 {
+		return time.Time{}, false
+	}
+	return Date(year, month, day), true
+}
+
+// --------------------------------------------------------
+
+const (
+	minInterval      = 1
+	maxInterval      = 99
+	maxIntervalSteps = 1000
+)
+
+// intervalUnit задаёт период, в котором считается интервал правила
+type intervalUnit int
+
+const (
+	intervalWeek intervalUnit = iota
+	intervalMonth
+	intervalYear
+)
+
+// IntervalRepeat оборачивает правило w, m или y и оставляет только каждую n-ю неделю, месяц или год.
+// Сигнатура: <правило> /<число>, например w 1 /2 — каждый второй понедельник,
+// m 15 /3 — 15-е число каждого третьего месяца, y /2 — раз в два года.
+// Отсчёт периодов ведётся от исходной даты задачи (date).
+type IntervalRepeat struct {
+	rule     RepeatRule
+	unit     intervalUnit
+	interval int
+}
+
+// parseInterval разбирает последнюю часть правила вида /<число>
+func parseInterval(part string) (int, error) {
+	num, err := strconv.Atoi(strings.TrimPrefix(part, "/"))
+	if err != nil || num < minInterval || num > maxInterval {
+		return 0, fmt.Errorf("Error in checking interval in repeat rule, got '%s'", part)
+	}
+	return num, nil
+}
+
+// NewIntervalRepeat создаёт правило с интервалом для правил w, m и y
+func NewIntervalRepeat(rule RepeatRule, identifier string, interval int) (*IntervalRepeat, error) {
+	var unit intervalUnit
+	switch identifier {
+	case "w":
+		unit = intervalWeek
+	case "m":
+		unit = intervalMonth
+	case "y":
+		unit = intervalYear
+	default:
+		return nil, fmt.Errorf("Interval is not supported for repeat identifier %s", identifier)
+	}
+	return &IntervalRepeat{rule: rule, unit: unit, interval: interval}, nil
+}
+
+// GetNextDate вычисляет следующую дату по вложенному правилу, пропуская периоды вне цикла
+func (ir *IntervalRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	current := now
+	for i := 0; i < maxIntervalSteps; i++ {
+		next, err := ir.rule.GetNextDate(current, date)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ir.periodsBetween(date, next)%ir.interval == 0 {
+			return next, nil
+		}
+		current = next
+	}
+	return time.Time{}, fmt.Errorf("Error in searching next date for interval repeat rule")
+}
+
+// periodsBetween считает количество недель, месяцев или лет между anchor и t
+func (ir *IntervalRepeat) periodsBetween(anchor time.Time, t time.Time) int {
+	switch ir.unit {
+	case intervalWeek:
+		days := int(weekStart(t).Sub(weekStart(anchor)).Hours() / 24)
+		return days / 7
+	case intervalMonth:
+		return (t.Year()*12 + int(t.Month())) - (anchor.Year()*12 + int(anchor.Month()))
+	default:
+		return t.Year() - anchor.Year()
+	}
+}
+
+// weekStart возвращает понедельник недели, в которую входит дата
+func weekStart(t time.Time) time.Time {
+	t = Date(t.Year(), int(t.Month()), t.Day())
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// --------------------------------------------------------
+
+// ErrNoNextDate возвращается, когда у правила больше нет следующих дат (достигнуто условие окончания)
+var ErrNoNextDate = errors.New("no next date for repeat rule")
+
+const (
+	untilKeyword = "until"
+	countKeyword = "count"
+	maxCount     = 1000
+)
+
+// UntilRepeat ограничивает вложенное правило датой окончания (включительно)
+// Сигнатура: <правило> until <дата в формате 20060102>, например d 7 until 20271231
+type UntilRepeat struct {
+	rule  RepeatRule
+	until time.Time
+}
+
+// GetNextDate вычисляет следующую дату по вложенному правилу
+// и возвращает ErrNoNextDate, если она позже даты окончания (день окончания входит целиком)
+func (ur *UntilRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	next, _, err := ur.nextWithBase(now, date)
+	return next, err
+}
+
+func (ur *UntilRepeat) nextWithBase(now time.Time, date time.Time) (time.Time, time.Time, error) {
+	next, base, err := NextDateWithBase(ur.rule, now, date)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !next.Before(ur.until.AddDate(0, 0, 1)) {
+		return time.Time{}, time.Time{}, ErrNoNextDate
+	}
+	return next, base, nil
+}
+
+// CountRepeat хранит число повторений условия count.
+// Даты не ограничивает: остаток повторений хранится в задаче и уменьшается при выполнении.
+type CountRepeat struct {
+	rule      RepeatRule
+	count     int
+	remaining int // сколько повторений осталось у задачи, 0 — не известно (описывается только count)
+}
+
+// SetRemaining задаёт правилу с условием count остаток повторений задачи для описания правила.
+// У правил без count ничего не меняется.
+func SetRemaining(rule RepeatRule, remaining int) {
+	switch r := rule.(type) {
+	case *CountRepeat:
+		r.remaining = remaining
+	case *RRuleRepeat:
+		r.remaining = remaining
+	}
+}
+
+// GetNextDate вычисляет следующую дату по вложенному правилу
+func (cr *CountRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	return cr.rule.GetNextDate(now, date)
+}
+
+func (cr *CountRepeat) nextWithBase(now time.Time, date time.Time) (time.Time, time.Time, error) {
+	return NextDateWithBase(cr.rule, now, date)
+}
+
+// endCondition хранит условие окончания правила: дату until или число повторений count
+type endCondition struct {
+	until time.Time
+	count int
+}
+
+// splitEndCondition отделяет от правила условие окончания вида until <дата> или count <число>
+// Сигнатура: <правило> count <число> — задача выполняется указанное число раз.
+func splitEndCondition(rule []string) ([]string, endCondition, error) {
+	cond := endCondition{}
+	if len(rule) < 3 {
+		return rule, cond, nil
+	}
+
+	keyword, value := rule[len(rule)-2], rule[len(rule)-1]
+	switch keyword {
+	case untilKeyword:
+		until, err := time.Parse("20060102", value)
+		if err != nil {
+			return nil, cond, fmt.Errorf("Error in checking until date in repeat rule, got '%s'", value)
+		}
+		cond.until = until
+	case countKeyword:
+		num, err := strconv.Atoi(value)
+		if err != nil || num < 1 || num > maxCount {
+			return nil, cond, fmt.Errorf("Error in checking count in repeat rule, got '%s'", value)
+		}
+		cond.count = num
+	default:
+		return rule, cond, nil
+	}
+	return rule[:len(rule)-2], cond, nil
+}
+
+// RepeatCount возвращает число повторений из условия count (или COUNT для RRULE) или 0, если оно не задано
+func RepeatCount(repeat string) (int, error) {
+	if IsRRule(repeat) {
+		rr, err := ParseRRuleRepeat(repeat)
+		if err != nil {
+			return 0, err
+		}
+		return rr.Count(), nil
+	}
+
+	_, cond, err := splitEndCondition(strings.Split(repeat, " "))
+	if err != nil {
+		return 0, err
+	}
+	return cond.count, nil
+}
+
+// --------------------------------------------------------
+
+// SkipRepeat пропускает даты-исключения вложенного правила (праздники, отпуск и т.п.)
+type SkipRepeat struct {
+	rule RepeatRule
+	skip map[string]bool
+	last string // последняя дата-исключение в формате 20060102, дальше неё поиск не идёт
+}
+
+// NewSkipRepeat создаёт правило с датами-исключениями в формате 20060102
+func NewSkipRepeat(rule RepeatRule, dates []string) (*SkipRepeat, error) {
+	skip := make(map[string]bool, len(dates))
+	last := ""
+	for _, d := range dates {
+		if _, err := time.Parse("20060102", d); err != nil {
+			return nil, fmt.Errorf("Error in checking skip date, got '%s'", d)
+		}
+		skip[d] = true
+		if d > last {
+			last = d
+		}
+	}
+	return &SkipRepeat{rule: rule, skip: skip, last: last}, nil
+}
+
+// GetNextDate вычисляет следующую дату по вложенному правилу, перепрыгивая даты-исключения.
+// Поиск ограничен не числом шагов, а последней датой-исключением: следующая дата ищется от предыдущей
+// найденной, и если отсчёт ушёл за последнюю дату-исключение, а дата всё ещё исключена, возвращается ошибка.
+// Поэтому правилам h и min можно исключать целые дни.
+func (sr *SkipRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	next, _, err := sr.nextWithBase(now, date)
+	return next, err
+}
+
+func (sr *SkipRepeat) nextWithBase(now time.Time, date time.Time) (time.Time, time.Time, error) {
+	current := now
+	for {
+		next, base, err := NextDateWithBase(sr.rule, current, date)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if !sr.skip[next.Format("20060102")] {
+			return next, base, nil
+		}
+		if !next.After(current) || current.Format("20060102") > sr.last {
+			return time.Time{}, time.Time{}, fmt.Errorf("Error in searching next date for repeat rule with skip dates")
+		}
+		current = next
+	}
+}
+
+// --------------------------------------------------------
+
+const (
+	businessKeyword = "bd"
+	shiftKeyword    = "shift"
+)
+
+// WorkingDayChecker определяет, является ли дата рабочим днём
+type WorkingDayChecker interface {
+	IsWorkingDay(t time.Time) bool
+}
+
+// weekendChecker считает рабочими все дни, кроме субботы и воскресенья
+type weekendChecker struct{}
+
+func (weekendChecker) IsWorkingDay(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// workingDays — календарь рабочих дней для правил d <число> bd и модификатора shift
+var workingDays WorkingDayChecker = weekendChecker{}
+
+// SetWorkingDayChecker задаёт календарь рабочих дней (например, с праздниками из файла)
+func SetWorkingDayChecker(c WorkingDayChecker) {
+	workingDays = c
+}
+
+// addWorkingDays прибавляет к дате n рабочих дней
+func addWorkingDays(t time.Time, n int) time.Time {
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if workingDays.IsWorkingDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// ShiftRepeat переносит дату вложенного правила m или y на ближайший рабочий день, если она выпала на выходной.
+// Сигнатура: <правило> shift, например m 15 shift или y shift
+type ShiftRepeat struct {
+	rule RepeatRule
+}
+
+// maxShiftDays — на сколько дней назад от now искать дату по правилу, перенос которой ещё не наступил
+const maxShiftDays = 31
+
+// GetNextDate вычисляет следующую дату по вложенному правилу и сдвигает её вперёд до рабочего дня.
+// date — дата по правилу до переноса (см. NextDateWithBase).
+func (sr *ShiftRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	next, _, err := sr.nextWithBase(now, date)
+	return next, err
+}
+
+// nextWithBase перебирает даты вложенного правила от исходной даты date, пока перенесённая дата не станет позже now.
+// Отсчёт всегда идёт от даты по правилу, а не от перенесённой, поэтому перенос не накапливается.
+func (sr *ShiftRepeat) nextWithBase(now time.Time, date time.Time) (time.Time, time.Time, error) {
+	current := now.AddDate(0, 0, -maxShiftDays)
+	if current.Before(date) {
+		current = date
+	}
+	for {
+		base, err := sr.rule.GetNextDate(current, date)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		next := shiftToWorkingDay(base)
+		if next.After(now) {
+			return next, base, nil
+		}
+		if !base.After(current) {
+			return time.Time{}, time.Time{}, fmt.Errorf("Error in searching next date for repeat rule with shift")
+		}
+		current = base
+	}
+}
+
+// shiftToWorkingDay сдвигает дату вперёд до ближайшего рабочего дня
+func shiftToWorkingDay(t time.Time) time.Time {
+	for i := 0; i < maxDays && !workingDays.IsWorkingDay(t); i++ {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// baseRule — правило, следующая дата которого может отличаться от даты по правилу (модификатор shift)
+type baseRule interface {
+	nextWithBase(now time.Time, date time.Time) (next time.Time, base time.Time, err error)
+}
+
+// NextDateWithBase вычисляет следующую дату правила и дату по правилу до переноса на рабочий день.
+// Для правил без shift обе даты совпадают. Дату base нужно сохранить и в следующий раз передать как date:
+// если считать от перенесённой даты, перенос накапливается (y shift: 1 января → 3 января → 3 января следующего года).
+func NextDateWithBase(rule RepeatRule, now time.Time, date time.Time) (time.Time, time.Time, error) {
+	if br, ok := rule.(baseRule); ok {
+		return br.nextWithBase(now, date)
+	}
+	next, err := rule.GetNextDate(now, date)
+	return next, next, err
+}
+
 // ----------------------------------------------------------------
 
 type RepeatRule interface {
 	GetNextDate(now time.Time, date time.Time) (time.Time, error)
+	Describe(lang string) string // описание правила на языке LangRu или LangEn
 }
 
 // ----------------------------------------------------------------
@@ -331,11 +853,49 @@ func ParseRepeat(now time.Time, date time.Time, repeat string) (RepeatRule, erro
 		return nil, fmt.Errorf("Expected repeat, got an empty string.")
 	}
 
+	if IsRRule(repeat) {
+		return ParseRRuleRepeat(repeat)
+	}
+
 	rule := strings.Split(repeat, " ")
 
 	var parsedRepeat RepeatRule
 	var err error
 
+	// условие окончания until или count всегда стоит в конце правила
+	rule, cond, err := splitEndCondition(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// модификаторы /<число> (интервал) и shift стоят после основного правила, каждый не больше одного раза
+	interval := minInterval
+	hasInterval, shift := false, false
+	for len(rule) > 1 {
+		last := rule[len(rule)-1]
+		if strings.HasPrefix(last, "/") {
+			if hasInterval {
+				return nil, fmt.Errorf("Modifier /<number> is repeated in '%s'", repeat)
+			}
+			interval, err = parseInterval(last)
+			if err != nil {
+				return nil, err
+			}
+			hasInterval = true
+		} else if last == shiftKeyword {
+			if rule[0] != "m" && rule[0] != "y" {
+				return nil, fmt.Errorf("Modifier shift is supported only for repeat rules 'm' and 'y'")
+			}
+			if shift {
+				return nil, fmt.Errorf("Modifier shift is repeated in '%s'", repeat)
+			}
+			shift = true
+		} else {
+			break
+		}
+		rule = rule[:len(rule)-1]
+	}
+
 	log.Printf("rule[0] before switch is: %v", rule[0])
 	switch {
 	case rule[0] == "y":
@@ -353,6 +913,26 @@ func ParseRepeat(now time.Time, date time.Time, repeat string) (RepeatRule, erro
 		if err != nil {
 			return nil, err
 		}
+	case rule[0] == "h":
+		parsedRepeat, err = ParseHRepeat(rule)
+		if err != nil {
+			return nil, err
+		}
+	case rule[0] == "min":
+		parsedRepeat, err = ParseMinRepeat(rule)
+		if err != nil {
+			return nil, err
+		}
+	case rule[0] == "t":
+		parsedRepeat, err = ParseTRepeat(rule)
+		if err != nil {
+			return nil, err
+		}
+	case rule[0] == "m" && isMWeekdayRule(rule):
+		parsedRepeat, err = ParseMWeekdayRepeat(rule)
+		if err != nil {
+			return nil, err
+		}
 	case rule[0] == "m":
 		parsedRepeat, err = ParseMRepeat(rule, now, date)
 		if err != nil {
@@ -362,5 +942,23 @@ func ParseRepeat(now time.Time, date time.Time, repeat string) (RepeatRule, erro
 		return nil, fmt.Errorf("Unkown repeat identifier %s", rule[0])
 	}
 
+	if interval > minInterval {
+		parsedRepeat, err = NewIntervalRepeat(parsedRepeat, rule[0], interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if shift {
+		parsedRepeat = &ShiftRepeat{rule: parsedRepeat}
+	}
+
+	if !cond.until.IsZero() {
+		parsedRepeat = &UntilRepeat{rule: parsedRepeat, until: cond.until}
+	}
+	if cond.count > 0 {
+		parsedRepeat = &CountRepeat{rule: parsedRepeat, count: cond.count}
+	}
+
 	return parsedRepeat, nil
 }
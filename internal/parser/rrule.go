@@ -0,0 +1,295 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	rrulePrefix = "RRULE:"
+	// maxRRuleDays ограничивает перебор дней при поиске следующей даты по RRULE
+	maxRRuleDays = 366 * 400
+)
+
+// rruleFreq — частота правила RRULE (FREQ)
+type rruleFreq int
+
+const (
+	freqDaily rruleFreq = iota
+	freqWeekly
+	freqMonthly
+	freqYearly
+)
+
+var rruleFreqs = map[string]rruleFreq{
+	"DAILY":   freqDaily,
+	"WEEKLY":  freqWeekly,
+	"MONTHLY": freqMonthly,
+	"YEARLY":  freqYearly,
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RRuleRepeat хранит правило повторения в формате iCalendar RRULE (RFC 5545).
+// Поддерживаются части FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY (в том числе 2TU, -1FR),
+// BYMONTHDAY (в том числе отрицательные), BYMONTH, COUNT и UNTIL.
+// Например: FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2. Отсчёт периодов ведётся от исходной даты задачи (date).
+type RRuleRepeat struct {
+	freq       rruleFreq
+	interval   int
+	byDay      []nthWeekday // n == 0 означает любой такой день недели в периоде
+	byMonthDay []int
+	byMonth    []int
+	count      int
+	remaining  int // остаток повторений задачи для описания, см. SetRemaining
+	until      time.Time
+}
+
+// IsRRule определяет, записано ли правило в формате RRULE
+func IsRRule(repeat string) bool {
+	upper := strings.ToUpper(repeat)
+	return strings.HasPrefix(upper, rrulePrefix) || strings.HasPrefix(upper, "FREQ=")
+}
+
+// ParseRRuleRepeat заполняет структуру RRuleRepeat
+func ParseRRuleRepeat(repeat string) (*RRuleRepeat, error) {
+	rule := strings.ToUpper(strings.TrimSpace(repeat))
+	rule = strings.TrimPrefix(rule, rrulePrefix)
+
+	rr := &RRuleRepeat{interval: 1, freq: -1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("Error in RRULE part '%s'", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			freq, ok := rruleFreqs[value]
+			if !ok {
+				return nil, fmt.Errorf("Unsupported RRULE frequency '%s'", value)
+			}
+			rr.freq = freq
+		case "INTERVAL":
+			rr.interval, err = strconv.Atoi(value)
+			if err != nil || rr.interval < minInterval || rr.interval > maxInterval {
+				return nil, fmt.Errorf("Error in RRULE interval, got '%s'", value)
+			}
+		case "BYDAY":
+			rr.byDay, err = parseRRuleByDay(value)
+		case "BYMONTHDAY":
+			rr.byMonthDay, err = parseRRuleInts(value, -31, 31)
+		case "BYMONTH":
+			rr.byMonth, err = parseRRuleInts(value, 1, 12)
+		case "COUNT":
+			rr.count, err = strconv.Atoi(value)
+			if err != nil || rr.count < 1 || rr.count > maxCount {
+				return nil, fmt.Errorf("Error in RRULE count, got '%s'", value)
+			}
+		case "UNTIL":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("Error in RRULE until, got '%s'", value)
+			}
+			rr.until, err = time.Parse("20060102", value[:8])
+		case "WKST":
+			// неделя всегда начинается с понедельника
+		default:
+			return nil, fmt.Errorf("Unsupported RRULE part '%s'", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error in RRULE part '%s'", part)
+		}
+	}
+
+	if rr.freq < 0 {
+		return nil, fmt.Errorf("RRULE must contain FREQ")
+	}
+	for _, wd := range rr.byDay {
+		// номер дня недели имеет смысл только внутри месяца или года, иначе правило не совпадёт ни с одной датой
+		if wd.n != 0 && rr.freq != freqMonthly && rr.freq != freqYearly {
+			return nil, fmt.Errorf("RRULE weekday number is allowed only with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	if rr.count > 0 && !rr.until.IsZero() {
+		return nil, fmt.Errorf("RRULE must not contain both COUNT and UNTIL")
+	}
+	return rr, nil
+}
+
+// parseRRuleByDay разбирает список дней недели вида MO,WE или 2TU,-1FR
+func parseRRuleByDay(value string) ([]nthWeekday, error) {
+	result := []nthWeekday{}
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("Error in RRULE weekday '%s'", item)
+		}
+		weekday, ok := rruleWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("Error in RRULE weekday '%s'", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			num, err := strconv.Atoi(prefix)
+			if err != nil || num == 0 || num < -53 || num > 53 {
+				return nil, fmt.Errorf("Error in RRULE weekday '%s'", item)
+			}
+			n = num
+		}
+		result = append(result, nthWeekday{n: n, weekday: weekday})
+	}
+	return result, nil
+}
+
+// parseRRuleInts разбирает список чисел из диапазона [min, max], ноль недопустим
+func parseRRuleInts(value string, min, max int) ([]int, error) {
+	result := []int{}
+	for _, item := range strings.Split(value, ",") {
+		num, err := strconv.Atoi(item)
+		if err != nil || num == 0 || num < min || num > max {
+			return nil, fmt.Errorf("Error in RRULE value '%s'", item)
+		}
+		result = append(result, num)
+	}
+	return result, nil
+}
+
+// Count возвращает ограничение COUNT или 0, если оно не задано
+func (rr *RRuleRepeat) Count() int {
+	return rr.count
+}
+
+// GetNextDate вычисляет следующую дату по правилу RRULE.
+// Дни перебираются после более поздней из дат now и date, пока не найдётся подходящий.
+func (rr *RRuleRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	anchor := Date(date.Year(), int(date.Month()), date.Day())
+	start := startDateForMWrule(now, date)
+	day := Date(start.Year(), int(start.Month()), start.Day())
+
+	for i := 0; i < maxRRuleDays; i++ {
+		day = day.AddDate(0, 0, 1)
+		if !rr.until.IsZero() && day.After(rr.until) {
+			return time.Time{}, ErrNoNextDate
+		}
+		if rr.matches(anchor, day) {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Error in searching next date for RRULE")
+}
+
+// matches проверяет, подходит ли день под правило
+func (rr *RRuleRepeat) matches(anchor time.Time, day time.Time) bool {
+	if rr.periodsBetween(anchor, day)%rr.interval != 0 {
+		return false
+	}
+	if len(rr.byMonth) > 0 && !containsInt(rr.byMonth, int(day.Month())) {
+		return false
+	}
+	if len(rr.byMonthDay) > 0 && !rr.matchesMonthDay(day) {
+		return false
+	}
+	if len(rr.byDay) > 0 {
+		return rr.matchesDay(day)
+	}
+
+	// без BYDAY и BYMONTHDAY день берётся из исходной даты
+	switch rr.freq {
+	case freqWeekly:
+		return day.Weekday() == anchor.Weekday()
+	case freqMonthly:
+		return len(rr.byMonthDay) > 0 || day.Day() == anchor.Day()
+	case freqYearly:
+		if len(rr.byMonthDay) > 0 {
+			return true
+		}
+		if len(rr.byMonth) > 0 {
+			return day.Day() == anchor.Day()
+		}
+		return day.Month() == anchor.Month() && day.Day() == anchor.Day()
+	}
+	return true
+}
+
+// periodsBetween считает количество периодов FREQ между anchor и day
+func (rr *RRuleRepeat) periodsBetween(anchor time.Time, day time.Time) int {
+	switch rr.freq {
+	case freqWeekly:
+		return int(weekStart(day).Sub(weekStart(anchor)).Hours()/24) / 7
+	case freqMonthly:
+		return (day.Year()*12 + int(day.Month())) - (anchor.Year()*12 + int(anchor.Month()))
+	case freqYearly:
+		return day.Year() - anchor.Year()
+	}
+	return int(day.Sub(anchor).Hours() / 24)
+}
+
+// matchesMonthDay проверяет BYMONTHDAY, отрицательные числа считаются от конца месяца
+func (rr *RRuleRepeat) matchesMonthDay(day time.Time) bool {
+	lastDay := Date(day.Year(), int(day.Month())+1, 0).Day()
+	for _, d := range rr.byMonthDay {
+		if d > 0 && d == day.Day() {
+			return true
+		}
+		if d < 0 && lastDay+d+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay проверяет BYDAY. Номер дня недели (2TU, -1FR) считается внутри месяца
+// для FREQ=MONTHLY и для FREQ=YEARLY с BYMONTH, иначе — внутри года.
+func (rr *RRuleRepeat) matchesDay(day time.Time) bool {
+	for _, wd := range rr.byDay {
+		if wd.weekday != day.Weekday() {
+			continue
+		}
+		if wd.n == 0 {
+			return true
+		}
+		if rr.freq == freqMonthly || (rr.freq == freqYearly && len(rr.byMonth) > 0) {
+			if d, ok := nthWeekdayOfMonth(day.Year(), int(day.Month()), wd); ok && d.Equal(day) {
+				return true
+			}
+			continue
+		}
+		if rr.freq == freqYearly && nthWeekdayOfYear(day) == wd.n {
+			return true
+		}
+		if rr.freq == freqYearly && -nthWeekdayOfYearFromEnd(day) == wd.n {
+			return true
+		}
+	}
+	return false
+}
+
+// nthWeekdayOfYear возвращает порядковый номер дня недели даты в году (1 — первый такой день)
+func nthWeekdayOfYear(day time.Time) int {
+	return (day.YearDay()-1)/7 + 1
+}
+
+// nthWeekdayOfYearFromEnd возвращает порядковый номер дня недели даты от конца года (1 — последний)
+func nthWeekdayOfYearFromEnd(day time.Time) int {
+	daysInYear := Date(day.Year(), 12, 31).YearDay()
+	return (daysInYear-day.YearDay())/7 + 1
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxHours   = 168     // не больше недели
+	maxMinutes = 24 * 60 // не больше суток
+	timeLayout = "15:04" // формат времени в правиле t
+)
+
+// IsSubDayRule определяет, повторяется ли правило чаще раза в день (h, min, t).
+// Для таких правил важны не только даты, но и время.
+func IsSubDayRule(repeat string) bool {
+	identifier, _, _ := strings.Cut(repeat, " ")
+	return identifier == "h" || identifier == "min" || identifier == "t"
+}
+
+// stepNextDate прибавляет к date шаг step столько раз, чтобы результат был позже now (хотя бы один раз)
+func stepNextDate(now time.Time, date time.Time, step time.Duration) time.Time {
+	steps := int64(1)
+	if now.After(date) {
+		steps = int64(now.Sub(date)/step) + 1
+	}
+	return date.Add(time.Duration(steps) * step)
+}
+
+// parseStep разбирает число правил h и min
+func parseStep(rule []string, max int) (int, error) {
+	if len(rule) != 2 {
+		return 0, fmt.Errorf("error in %s rule", rule[0])
+	}
+	num, err := strconv.Atoi(rule[1])
+	if err != nil || num < 1 || num > max {
+		return 0, fmt.Errorf("expected number from 1 to %d in repeat rule '%s', got '%s'", max, rule[0], rule[1])
+	}
+	return num, nil
+}
+
+// --------------------------------------------------------
+
+// HRepeat хранит число часов правила h
+// Сигнатура: h <число> — задача повторяется каждые указанное число часов (не больше 168).
+type HRepeat struct {
+	hours int
+}
+
+// ParseHRepeat заполняет структуру HRepeat
+func ParseHRepeat(rule []string) (*HRepeat, error) {
+	num, err := parseStep(rule, maxHours)
+	if err != nil {
+		return nil, err
+	}
+	return &HRepeat{hours: num}, nil
+}
+
+// GetNextDate вычисляет следующий момент по правилу h
+func (hr *HRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	return stepNextDate(now, date, time.Duration(hr.hours)*time.Hour), nil
+}
+
+// Describe возвращает описание правила h
+func (hr *HRepeat) Describe(lang string) string {
+	return every(lang, hr.hours, "hour", "hours", "каждый", "час", "часа", "часов")
+}
+
+// --------------------------------------------------------
+
+// MinRepeat хранит число минут правила min
+// Сигнатура: min <число> — задача повторяется каждые указанное число минут (не больше 1440).
+type MinRepeat struct {
+	minutes int
+}
+
+// ParseMinRepeat заполняет структуру MinRepeat
+func ParseMinRepeat(rule []string) (*MinRepeat, error) {
+	num, err := parseStep(rule, maxMinutes)
+	if err != nil {
+		return nil, err
+	}
+	return &MinRepeat{minutes: num}, nil
+}
+
+// GetNextDate вычисляет следующий момент по правилу min
+func (mr *MinRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	return stepNextDate(now, date, time.Duration(mr.minutes)*time.Minute), nil
+}
+
+// Describe возвращает описание правила min
+func (mr *MinRepeat) Describe(lang string) string {
+	return every(lang, mr.minutes, "minute", "minutes", "каждую", "минуту", "минуты", "минут")
+}
+
+// --------------------------------------------------------
+
+// TRepeat хранит время суток правила t в минутах от полуночи
+// Сигнатура: t <через запятую время в формате 15:04> — задача повторяется каждый день в указанное время.
+type TRepeat struct {
+	minutes []int
+}
+
+// ParseTRepeat заполняет структуру TRepeat
+func ParseTRepeat(rule []string) (*TRepeat, error) {
+	if len(rule) != 2 {
+		return nil, fmt.Errorf("error in t rule")
+	}
+
+	minutes := []int{}
+	for _, item := range strings.Split(rule[1], ",") {
+		t, err := time.Parse(timeLayout, item)
+		if err != nil {
+			return nil, fmt.Errorf("error in checking time in repeat rule 't', got '%s'", item)
+		}
+		minutes = append(minutes, t.Hour()*60+t.Minute())
+	}
+	sort.Ints(minutes)
+	return &TRepeat{minutes: minutes}, nil
+}
+
+// GetNextDate вычисляет ближайший момент по правилу t после более поздней из дат now и date
+func (tr *TRepeat) GetNextDate(now time.Time, date time.Time) (time.Time, error) {
+	start := startDateForMWrule(now, date)
+	day := Date(start.Year(), int(start.Month()), start.Day())
+
+	for i := 0; i < 2; i++ {
+		for _, m := range tr.minutes {
+			next := day.Add(time.Duration(m) * time.Minute)
+			if next.After(start) {
+				return next, nil
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, fmt.Errorf("Error in searching next date for repeat rule 't'")
+}
+
+// Describe возвращает описание правила t
+func (tr *TRepeat) Describe(lang string) string {
+	times := []string{}
+	for _, m := range tr.minutes {
+		times = append(times, fmt.Sprintf("%02d:%02d", m/60, m%60))
+	}
+	if lang == LangEn {
+		return "every day at " + strings.Join(times, ", ")
+	}
+	return "каждый день в " + strings.Join(times, ", ")
+}
@@ -8,12 +8,47 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+var signinOnce sync.Once
+
+// authToken возвращает Token или, если он не задан, токен доступа, полученный входом с паролем Password.
+// Токены доступа живут недолго, поэтому постоянный токен в настройках не подходит.
+func authToken() (string, error) {
+	if len(Token) > 0 || len(Password) == 0 {
+		return Token, nil
+	}
+	var err error
+	signinOnce.Do(func() {
+		var data []byte
+		data, err = json.Marshal(map[string]string{"password": Password})
+		if err != nil {
+			return
+		}
+		var resp *http.Response
+		resp, err = http.Post(getURL("api/signin"), "application/json", bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		var ret map[string]string
+		if err = json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+			return
+		}
+		if ret["token"] == "" {
+			err = fmt.Errorf("signin: %s", ret["error"])
+			return
+		}
+		Token = ret["token"]
+	})
+	return Token, err
+}
+
 func requestJSON(apipath string, values map[string]any, method string) ([]byte, error) {
 	var (
 		data []byte
@@ -35,7 +70,11 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
-	if len(Token) > 0 {
+	token, err := authToken()
+	if err != nil {
+		return nil, err
+	}
+	if len(token) > 0 {
 		jar, err := cookiejar.New(nil)
 		if err != nil {
 			return nil, err
@@ -43,7 +82,7 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 		jar.SetCookies(req.URL, []*http.Cookie{
 			{
 				Name:  "token",
-				Value: Token,
+				Value: token,
 			},
 		})
 		client.Jar = jar
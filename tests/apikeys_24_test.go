@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ts := newTestServer(t)
+	today := time.Now().Format(`20060102`)
+
+	newKey := func(name string, readOnly bool) map[string]any {
+		var created map[string]any
+		status := ts.do(t, http.MethodPost, "/api/keys", map[string]any{"name": name, "read_only": readOnly}, &created)
+		require.Equal(t, http.StatusCreated, status)
+		return created
+	}
+	full := newKey("ci", false)
+	key := full["key"].(string)
+	assert.True(t, strings.HasPrefix(key, "todo_"))
+	assert.Equal(t, "ci", full["name"])
+	assert.NotEmpty(t, full["created_at"])
+	assert.Equal(t, http.StatusBadRequest, ts.do(t, http.MethodPost, "/api/keys", map[string]any{"name": " "}, nil))
+
+	// ключ работает без куки, через заголовок Authorization
+	session := ts.token
+	ts.token, ts.bearer = "", key
+	assert.Equal(t, http.StatusCreated, ts.do(t, http.MethodPost, "/api/task", map[string]any{"date": today, "title": "Из CI"}, nil))
+	var user map[string]any
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/user", nil, &user))
+	assert.Equal(t, "admin", user["login"])
+	ts.bearer = "todo_unknown"
+	assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+
+	// токен доступа тоже можно передать в заголовке
+	ts.bearer = session
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+
+	// ключ только для чтения не меняет данные
+	readOnly := newKey("отчёты", true)
+	ts.bearer = readOnly["key"].(string)
+	var list map[string][]map[string]string
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks", nil, &list))
+	assert.Len(t, list["tasks"], 1)
+	assert.Equal(t, http.StatusForbidden, ts.do(t, http.MethodPost, "/api/task", map[string]any{"date": today, "title": "Нельзя"}, nil))
+	assert.Equal(t, http.StatusForbidden, ts.do(t, http.MethodPost, "/api/keys", map[string]any{"name": "ещё"}, nil))
+	// право записи задано маршрутом, а не методом: выполнить задачу нельзя и запросом GET
+	taskID := list["tasks"][0]["id"]
+	assert.Equal(t, http.StatusMethodNotAllowed, ts.do(t, http.MethodGet, "/api/task/done?id="+taskID, nil, nil))
+	assert.Equal(t, http.StatusForbidden, ts.do(t, http.MethodPost, "/api/task/done?id="+taskID, nil, nil))
+	assert.Equal(t, http.StatusForbidden, ts.do(t, http.MethodPost, "/api/task/undo?id="+taskID+"&token=x", nil, nil))
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks/"+taskID, nil, nil))
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks", nil, &list))
+	assert.Len(t, list["tasks"], 1)
+
+	// в списке ключей нет самих ключей, но видно начало ключа и время использования
+	ts.token, ts.bearer = session, ""
+	var keys map[string][]map[string]any
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/keys", nil, &keys))
+	if assert.Len(t, keys["keys"], 2) {
+		assert.Nil(t, keys["keys"][0]["key"])
+		assert.True(t, strings.HasPrefix(key, keys["keys"][0]["prefix"].(string)))
+		assert.NotEmpty(t, keys["keys"][0]["last_used"])
+		assert.Equal(t, true, keys["keys"][1]["read_only"])
+	}
+
+	// чужой ключ удалить нельзя, отозванный ключ не действует
+	id := full["id"].(string)
+	ts.token = ""
+	require.Equal(t, http.StatusCreated,
+		ts.do(t, http.MethodPost, "/api/signup", map[string]any{"login": "alice", "password": "alice-secret"}, nil))
+	ts.signinAs(t, "alice", "alice-secret")
+	assert.Equal(t, http.StatusNotFound, ts.do(t, http.MethodDelete, "/api/keys/"+id, nil, nil))
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/keys", nil, &keys))
+	assert.Empty(t, keys["keys"])
+
+	ts.token = session
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodDelete, "/api/keys/"+id, nil, nil))
+	assert.Equal(t, http.StatusNotFound, ts.do(t, http.MethodDelete, "/api/keys/"+id, nil, nil))
+	ts.token, ts.bearer = "", key
+	assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, db.MigrateUp(conn))
+
+	for name, store := range map[string]repo.APIKeyStore{
+		"sqlite": repo.NewTasksRepository(conn),
+		"memory": repo.NewMemoryStore(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			id, err := store.AddAPIKey(models.APIKey{UserID: 1, Name: "ci", Prefix: "todo_abc", KeyHash: "hash-1", ReadOnly: true})
+			require.NoError(t, err)
+			_, err = store.AddAPIKey(models.APIKey{UserID: 2, Name: "чужой", Prefix: "todo_def", KeyHash: "hash-2"})
+			require.NoError(t, err)
+
+			key, err := store.GetAPIKeyByHash("hash-1")
+			require.NoError(t, err)
+			assert.Equal(t, "ci", key.Name)
+			assert.True(t, key.ReadOnly)
+			assert.NotEmpty(t, key.CreatedAt)
+			assert.Empty(t, key.LastUsed)
+
+			used := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+			require.NoError(t, store.TouchAPIKey(id, used))
+			keys, err := store.GetAPIKeys(1)
+			require.NoError(t, err)
+			if assert.Len(t, keys, 1) {
+				assert.Equal(t, "2026-05-01T10:00:00Z", keys[0].LastUsed)
+			}
+
+			assert.Error(t, store.DeleteAPIKey(2, id))
+			require.NoError(t, store.DeleteAPIKey(1, id))
+			_, err = store.GetAPIKeyByHash("hash-1")
+			assert.Error(t, err)
+			keys, err = store.GetAPIKeys(1)
+			require.NoError(t, err)
+			assert.Empty(t, keys)
+		})
+	}
+}
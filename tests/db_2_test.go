@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
 )
 
 type Task struct {
@@ -16,6 +16,15 @@ type Task struct {
 	Title   string `db:"title"`
 	Comment string `db:"comment"`
 	Repeat  string `db:"repeat"`
+
+	Remaining int64  `db:"remaining"`
+	Skip      string `db:"skip"`
+	Time      string `db:"time"`
+	Timezone  string `db:"timezone"`
+	Anchor    string `db:"anchor"`
+	DeletedAt string `db:"deleted_at"`
+	UserID    int64  `db:"user_id"`
+	RuleDate  string `db:"rule_date"`
 }
 
 func count(db *sqlx.DB) (int, error) {
@@ -29,7 +38,7 @@ func openDB(t *testing.T) *sqlx.DB {
 	if len(envFile) > 0 {
 		dbfile = envFile
 	}
-	db, err := sqlx.Connect("sqlite3", dbfile)
+	db, err := sqlx.Connect("sqlite", dbfile)
 	assert.NoError(t, err)
 	return db
 }
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchFilters(t *testing.T) {
+	if !Search {
+		return
+	}
+	now := time.Now()
+	soon := now.AddDate(0, 0, 2).Format(`20060102`)
+	later := now.AddDate(0, 0, 40).Format(`20060102`)
+	weekly := addTask(t, task{
+		date:    soon,
+		title:   "Сводный отчёт по складу",
+		comment: "Сверить остатки",
+		repeat:  "w 1,3",
+	})
+	daily := addTask(t, task{
+		date:   soon,
+		title:  "Сводный отчёт по кассе",
+		repeat: "d 1",
+	})
+	far := addTask(t, task{
+		date:    later,
+		title:   "Сводный отчёт за квартал",
+		comment: "Отправить в бухгалтерию",
+		repeat:  "w 5",
+	})
+
+	ids := func(search string) []string {
+		var result []string
+		for _, task := range getTasks(t, url.QueryEscape(search)) {
+			result = append(result, task["id"])
+		}
+		return result
+	}
+	assert.ElementsMatch(t, []string{weekly, daily, far}, ids("title:сводный"))
+	assert.ElementsMatch(t, []string{weekly, far}, ids("title:сводный repeat:w"))
+	assert.ElementsMatch(t, []string{weekly}, ids("title:сводный repeat:w before:"+later))
+	assert.ElementsMatch(t, []string{far}, ids("title:сводный after:"+soon+" has:comment"))
+	assert.ElementsMatch(t, []string{daily}, ids(`title:"отчёт по кассе"`))
+	assert.Empty(t, ids("title:остатки"), "title: ищет только в заголовке")
+	assert.ElementsMatch(t, []string{weekly}, ids("остатки repeat:w"))
+
+	// неверное значение фильтра — ошибка запроса
+	for _, search := range []string{"before:завтра", "repeat:x", "has:title"} {
+		body, err := requestJSON("api/tasks?search="+url.QueryEscape(search), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "error", search)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+func getHistory(t *testing.T, id string) []map[string]string {
+	body, err := requestJSON("api/tasks/"+id+"/history", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["history"]
+}
+
+func TestTaskHistory(t *testing.T) {
+	now := time.Now()
+	date := now.Format(`20060102`)
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":   date,
+		"title":  "Проверка огнетушителей",
+		"repeat": "d 7",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+	assert.Empty(t, getHistory(t, id))
+
+	ret, err = postJSON("api/task/done?id="+id, map[string]any{"note": "Все в норме"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	history := getHistory(t, id)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, id, history[0]["task_id"])
+		assert.Equal(t, date, history[0]["date"])
+		assert.Equal(t, "Все в норме", history[0]["note"])
+		assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), history[1]["date"])
+		assert.Empty(t, history[1]["note"])
+		_, err = time.Parse(time.RFC3339, history[1]["completed_at"])
+		assert.NoError(t, err)
+	}
+
+	// история одноразовой задачи остаётся и после её удаления
+	ret, err = postJSON("api/task", map[string]any{
+		"date":  date,
+		"title": "Разовая проверка",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+	assert.Len(t, getHistory(t, id), 1)
+}
+
+func TestDoneAtomic(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, db.MigrateUp(conn))
+	store := repo.NewTasksRepository(conn)
+
+	date := time.Now().Format(`20060102`)
+	id, err := store.AddTask(models.Task{Date: date, Title: "Полить цветы", Repeat: "d 3"})
+	require.NoError(t, err)
+
+	// запись в историю не удалась — задача остаётся на прежней дате
+	_, err = conn.Exec("DROP TABLE task_completions")
+	require.NoError(t, err)
+	_, err = store.PostTaskDone(id, "")
+	assert.Error(t, err)
+	task, err := store.GetTask(id)
+	require.NoError(t, err)
+	assert.Equal(t, date, task.Date)
+
+	// выполнение несуществующей задачи — ошибка, а не пустой успешный ответ
+	ts := newTestServer(t)
+	status := ts.do(t, http.MethodPost, "/api/task/done?id=100", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
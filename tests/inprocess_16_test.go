@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
+	"github.com/wisdomdevil/go_final_project/internal/calendar"
+	"github.com/wisdomdevil/go_final_project/internal/config"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/handlers"
+)
+
+// testServer запускает приложение в процессе теста на хранилище в памяти,
+// без внешнего сервера и файла БД
+type testServer struct {
+	*httptest.Server
+	token  string
+	bearer string // передаётся в заголовке Authorization: Bearer
+}
+
+// testKey — ключ подписи токенов тестового сервера
+var testKey = auth.SigningKey{ID: "test", Secret: []byte("test_secret_key_test_secret_key_")}
+
+func newTestConfig(t *testing.T, keys ...auth.SigningKey) *config.Config {
+	if len(keys) == 0 {
+		keys = []auth.SigningKey{testKey}
+	}
+	keySet, err := auth.NewKeySet(keys...)
+	require.NoError(t, err)
+	cfg, err := config.NewConfig("", keySet, "", "", "", "", "")
+	require.NoError(t, err)
+	return cfg
+}
+
+func newTestServer(t *testing.T) *testServer {
+	return newTestServerWith(t, newTestConfig(t), repo.NewMemoryStore())
+}
+
+// newTestServerWith запускает тестовый сервер с заданной конфигурацией и хранилищем
+// и входит администратором
+func newTestServerWith(t *testing.T, cfg *config.Config, store *repo.MemoryStore) *testServer {
+	require.NoError(t, handlers.BootstrapAdmin(store, cfg))
+	api := handlers.NewApi(store, store, cfg, calendar.NewCalendar())
+
+	ts := &testServer{Server: httptest.NewServer(handlers.NewRouter(api, ""))}
+	t.Cleanup(ts.Close)
+
+	var signin map[string]string
+	status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"password": cfg.AppPassword}, &signin)
+	require.Equal(t, http.StatusOK, status)
+	ts.token = signin["token"]
+	require.NotEmpty(t, ts.token)
+	return ts
+}
+
+// do выполняет запрос к тестовому серверу и разбирает JSON ответа в result
+func (ts *testServer) do(t *testing.T, method, path string, body map[string]any, result any) int {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(data))
+	require.NoError(t, err)
+	if ts.token != "" {
+		req.AddCookie(&http.Cookie{Name: "token", Value: ts.token})
+	}
+	if ts.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+ts.bearer)
+	}
+
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if result != nil && len(respBody) > 0 {
+		require.NoError(t, json.Unmarshal(respBody, result), string(respBody))
+	}
+	return resp.StatusCode
+}
+
+func TestInProcessTasks(t *testing.T) {
+	ts := newTestServer(t)
+	today := time.Now().Format(`20060102`)
+
+	var created map[string]int
+	status := ts.do(t, http.MethodPost, "/api/task", map[string]any{
+		"date":   today,
+		"title":  "Проверка без сервера",
+		"repeat": "d 2",
+	}, &created)
+	assert.Equal(t, http.StatusCreated, status)
+	id := created["id"]
+	assert.Equal(t, 1, id)
+
+	var list map[string][]map[string]string
+	ts.do(t, http.MethodGet, "/api/tasks", nil, &list)
+	if assert.Len(t, list["tasks"], 1) {
+		assert.Equal(t, "Проверка без сервера", list["tasks"][0]["title"])
+		assert.Equal(t, "каждые 2 дня", list["tasks"][0]["repeat_text"])
+	}
+
+	status = ts.do(t, http.MethodPost, "/api/task/done?id=1", nil, nil)
+	assert.Equal(t, http.StatusOK, status)
+	var task map[string]string
+	ts.do(t, http.MethodGet, "/api/tasks/1", nil, &task)
+	assert.Equal(t, time.Now().AddDate(0, 0, 2).Format(`20060102`), task["date"])
+
+	status = ts.do(t, http.MethodDelete, "/api/task?id=1", nil, nil)
+	assert.Equal(t, http.StatusOK, status)
+	ts.do(t, http.MethodGet, "/api/tasks", nil, &list)
+	assert.Empty(t, list["tasks"])
+	ts.do(t, http.MethodGet, "/api/trash", nil, &list)
+	assert.Len(t, list["tasks"], 1)
+
+	// без токена API недоступно
+	ts.token = ""
+	status = ts.do(t, http.MethodGet, "/api/tasks", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
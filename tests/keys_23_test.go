@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+)
+
+func TestKeySet(t *testing.T) {
+	first, err := auth.GenerateKey()
+	require.NoError(t, err)
+	second, err := auth.GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Len(t, first.Secret, auth.MinKeySize)
+
+	text := "# ключ подписи\n" + first.String() + "\n\n" + second.String() + "\n"
+	keys, err := auth.ParseKeySet(text)
+	require.NoError(t, err)
+	assert.Equal(t, first, keys.Signing())
+	key, ok := keys.Key(second.ID)
+	assert.True(t, ok)
+	assert.Equal(t, second, key)
+	_, ok = keys.Key("unknown")
+	assert.False(t, ok)
+
+	keys, err = auth.ParseKeySet(second.String() + "," + first.String())
+	require.NoError(t, err)
+	assert.Equal(t, second, keys.Signing())
+
+	for _, bad := range []string{
+		"",
+		"# только комментарий",
+		"без-идентификатора",
+		"short:" + "c2hvcnQ",
+		first.String() + "\n" + first.String(),
+		"bad id:" + strings.SplitN(first.String(), ":", 2)[1],
+	} {
+		_, err := auth.ParseKeySet(bad)
+		assert.Error(t, err, bad)
+	}
+
+	file := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(file, []byte(text), 0o600))
+	keys, err = auth.LoadKeySet("", file)
+	require.NoError(t, err)
+	assert.Equal(t, first, keys.Signing())
+	_, err = auth.LoadKeySet(first.String(), file)
+	assert.Error(t, err, "ключи заданы и в окружении, и в файле")
+	_, err = auth.LoadKeySet("", "")
+	assert.ErrorIs(t, err, auth.ErrNoKeys)
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	newKey, err := auth.GenerateKey()
+	require.NoError(t, err)
+	store := repo.NewMemoryStore()
+
+	// токен подписан старым ключом
+	old := newTestServerWith(t, newTestConfig(t, testKey), store)
+	token, _, err := jwt.NewParser().ParseUnverified(old.token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, testKey.ID, token.Header["kid"])
+
+	// после ротации новые токены подписываются новым ключом, а старые ещё действуют
+	rotated := newTestServerWith(t, newTestConfig(t, newKey, testKey), store)
+	token, _, err = jwt.NewParser().ParseUnverified(rotated.token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, newKey.ID, token.Header["kid"])
+	rotated.token = old.token
+	assert.Equal(t, http.StatusOK, rotated.do(t, http.MethodGet, "/api/tasks", nil, nil))
+
+	// когда старый ключ убран, подписанные им токены не принимаются
+	retired := newTestServerWith(t, newTestConfig(t, newKey), store)
+	assert.Equal(t, http.StatusOK, retired.do(t, http.MethodGet, "/api/tasks", nil, nil))
+	retired.token = old.token
+	assert.Equal(t, http.StatusUnauthorized, retired.do(t, http.MethodGet, "/api/tasks", nil, nil))
+
+	// токен без kid, подписанный прежним зашитым ключом, тоже
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "typ": "access", "jti": "legacy"}).
+		SignedString([]byte(auth.LegacySecretKey))
+	require.NoError(t, err)
+	retired.token = legacy
+	assert.Equal(t, http.StatusUnauthorized, retired.do(t, http.MethodGet, "/api/tasks", nil, nil))
+}
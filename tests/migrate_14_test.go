@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	conn := openDB(t)
+	defer conn.Close()
+
+	var versions []int
+	err := conn.Select(&versions, `SELECT version FROM schema_version ORDER BY version`)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, versions) {
+		// версии применяются по порядку, без пропусков
+		for i, v := range versions {
+			assert.Equal(t, i+1, v)
+		}
+		assert.GreaterOrEqual(t, versions[len(versions)-1], 6)
+	}
+}
+
+
+func TestMigrateUpTwiceSQLite(t *testing.T) {
+	testMigrateUpTwice(t, filepath.Join(t.TempDir(), "scheduler.db"))
+}
+
+// testMigrateUpTwice применяет миграции к пустой БД и повторно: второй запуск ничего не меняет
+func testMigrateUpTwice(t *testing.T, dsn string) {
+	conn, err := db.Open(dsn)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	version, err := db.CurrentVersion(conn)
+	require.NoError(t, err)
+	require.NoError(t, db.MigrateUp(conn))
+	latest, err := db.CurrentVersion(conn)
+	require.NoError(t, err)
+	assert.Greater(t, latest, version)
+
+	require.NoError(t, db.MigrateUp(conn))
+	version, err = db.CurrentVersion(conn)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+}
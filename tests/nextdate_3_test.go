@@ -74,6 +74,28 @@ func TestNextDate(t *testing.T) {
 		{"20240126", "w 7", "20240128"},
 		{"20230126", "w 4,5", "20240201"},
 		{"20230226", "w 8,4,5", ""},
+		{"20240126", "m 2tue", "20240213"},
+		{"20240101", "m -1fri", "20240223"},
+		{"20240126", "m 1mon 3,6", "20240304"},
+		{"20240126", "m 5thu 2", "20240229"},
+		{"20240126", "m 2tue,-1sun", "20240128"},
+		{"20240126", "m 6mon", ""},
+		{"20240126", "m 2tue 13", ""},
+		{"20240101", "w 1 /2", "20240129"},
+		{"20240101", "w 1 /3", "20240212"},
+		{"20231015", "m 15 /3", "20240415"},
+		{"20210301", "y /2", "20250301"},
+		{"20240126", "d 5 /2", ""},
+		{"20240126", "w 1 /0", ""},
+		{"20240126", "m 1 /2 /3", ""},
+		{"20240126", "y shift shift", ""},
+		{"20240126", "m 1 shift /2 shift", ""},
+		{"20240101", "d 10 until 20240210", "20240131"},
+		{"20240101", "d 10 until 20240130", ""},
+		{"20240101", "w 1 /2 until 20241231", "20240129"},
+		{"20240101", "d 10 count 3", "20240131"},
+		{"20240101", "d 10 count 0", ""},
+		{"20240101", "y until 2024", ""},
 	}
 	check()
 }
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tasksPage struct {
+	Tasks      []map[string]string `json:"tasks"`
+	NextCursor string              `json:"next_cursor"`
+}
+
+func getTasksPage(t *testing.T, params url.Values) tasksPage {
+	body, err := requestJSON("api/tasks?"+params.Encode(), nil, http.MethodGet)
+	require.NoError(t, err)
+
+	var page tasksPage
+	require.NoError(t, json.Unmarshal(body, &page), string(body))
+	return page
+}
+
+func TestTasksPagination(t *testing.T) {
+	if !Search {
+		return
+	}
+	date := time.Now().AddDate(0, 0, 3).Format(`20060102`)
+	var ids []string
+	for _, comment := range []string{"", "пагинатор", "пагинатор пагинатор пагинатор"} {
+		ids = append(ids, addTask(t, task{date: date, title: "Пагинатор", comment: comment}))
+	}
+
+	// по релевантности: чаще всего слово встречается в последней задаче
+	params := url.Values{"search": {"пагинатор"}, "limit": {"1"}}
+	var found []string
+	for {
+		page := getTasksPage(t, params)
+		require.LessOrEqual(t, len(page.Tasks), 1)
+		for _, task := range page.Tasks {
+			found = append(found, task["id"])
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+	assert.Equal(t, []string{ids[2], ids[1], ids[0]}, found)
+
+	page := getTasksPage(t, url.Values{"search": {"пагинатор"}, "sort": {"id"}, "order": {"desc"}, "limit": {"2"}})
+	if assert.Len(t, page.Tasks, 2) {
+		assert.Equal(t, ids[2], page.Tasks[0]["id"])
+		assert.Equal(t, ids[1], page.Tasks[1]["id"])
+	}
+	assert.NotEmpty(t, page.NextCursor)
+
+	// без параметров страницы ответ прежний, без next_cursor
+	body, err := requestJSON("api/tasks?search=пагинатор", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "next_cursor")
+
+	for _, params := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"1000"}},
+		{"sort": {"comment"}},
+		{"order": {"up"}},
+		{"cursor": {"не-курсор"}},
+	} {
+		body, err := requestJSON("api/tasks?"+params.Encode(), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "error", params.Encode())
+	}
+}
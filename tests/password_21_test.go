@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/auth"
+	"github.com/wisdomdevil/go_final_project/internal/calendar"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/handlers"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	hasher := auth.BcryptHasher{Cost: 4, LegacySecret: "secret"}
+
+	hash, err := hasher.Hash("пароль-1")
+	require.NoError(t, err)
+	other, err := hasher.Hash("пароль-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "у каждого хэша своя соль")
+
+	ok, rehash := hasher.Verify("пароль-1", hash)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+	ok, _ = hasher.Verify("пароль-2", hash)
+	assert.False(t, ok)
+
+	// хэш старого формата и хэш с меньшей стоимостью принимаются, но требуют пересчёта
+	ok, rehash = hasher.Verify("пароль-1", auth.LegacyHash("пароль-1", "secret"))
+	assert.True(t, ok)
+	assert.True(t, rehash)
+	ok, _ = hasher.Verify("пароль-2", auth.LegacyHash("пароль-1", "secret"))
+	assert.False(t, ok)
+	ok, rehash = auth.BcryptHasher{Cost: 5}.Verify("пароль-1", hash)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	_, err = hasher.Hash(strings.Repeat("x", 73))
+	assert.Error(t, err)
+}
+
+func TestSigninRehash(t *testing.T) {
+	cfg := newTestConfig(t)
+	store := repo.NewMemoryStore()
+	require.NoError(t, handlers.BootstrapAdmin(store, cfg))
+	admin, err := store.GetUserByLogin(handlers.AdminLogin)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(admin.PasswordHash, "$2"), "пароль администратора хранится в bcrypt")
+
+	// пользователь с хэшем старого формата
+	_, err = store.AddUser(models.User{Login: "old", PasswordHash: auth.LegacyHash("old-secret", auth.LegacySecretKey)})
+	require.NoError(t, err)
+
+	ts := &testServer{Server: httptest.NewServer(handlers.NewRouter(handlers.NewApi(store, store, cfg, calendar.NewCalendar()), ""))}
+	defer ts.Close()
+
+	status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"login": "old", "password": "wrong-secret"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	user, err := store.GetUserByLogin("old")
+	require.NoError(t, err)
+	assert.Equal(t, auth.LegacyHash("old-secret", auth.LegacySecretKey), user.PasswordHash)
+
+	ts.signinAs(t, "old", "old-secret")
+	user, err = store.GetUserByLogin("old")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$2"))
+	status = ts.do(t, http.MethodGet, "/api/user", nil, nil)
+	assert.Equal(t, http.StatusOK, status, "токен выдан уже с новым хэшем")
+
+	// повторный вход с пересчитанным хэшем
+	ts.signinAs(t, "old", "old-secret")
+}
+
+func TestSigninUnknownLoginTiming(t *testing.T) {
+	ts := newTestServer(t)
+
+	signin := func(login string) time.Duration {
+		start := time.Now()
+		status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"login": login, "password": "не-тот-пароль"}, nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		return time.Since(start)
+	}
+
+	// неизвестный логин проверяется так же долго, как неверный пароль: bcrypt считается в обоих случаях
+	signin("nobody")
+	known, unknown := signin("admin"), signin("nobody")
+	assert.Greater(t, unknown, known/2, "known %v, unknown %v", known, unknown)
+}
@@ -0,0 +1,419 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/dateutil"
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+	"github.com/wisdomdevil/go_final_project/internal/parser"
+)
+
+func TestDoneCount(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	id := addTask(t, task{
+		title:  "Повторить дважды",
+		repeat: "d 1 count 2",
+	})
+
+	var task Task
+	err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), task.Remaining)
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), task.Remaining)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+
+	// в описании правила задачи — остаток повторений, а не их общее число
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var described map[string]string
+	assert.NoError(t, json.Unmarshal(body, &described))
+	assert.Equal(t, "каждый день, остался 1 из 2 раз", described["repeat_text"])
+	text, err := dateutil.DescribeRepeat("FREQ=DAILY;COUNT=5", "en", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "every day, 3 of 5 times left", text)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+}
+
+func TestDoneUntil(t *testing.T) {
+	now := time.Now()
+	id := addTask(t, task{
+		title:  "Повторять до завтра",
+		repeat: "d 2 until " + now.AddDate(0, 0, 1).Format(`20060102`),
+	})
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+}
+
+func TestNextDateSkip(t *testing.T) {
+	tbl := []struct {
+		date   string
+		repeat string
+		skip   string
+		want   string
+	}{
+		{"20240120", "d 7", "20240127", "20240203"},
+		{"20240125", "w 1,2,3", "20240129,20240130", "20240131"},
+		{"20240125", "w 1,2,3", "2024013", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s&skip=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat), url.QueryEscape(v.skip))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.date, v.repeat, v.skip)
+	}
+}
+
+func TestTaskSkip(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	id := addTask(t, task{
+		date:   now.Format(`20060102`),
+		title:  "Полить цветы",
+		repeat: "d 1",
+	})
+
+	skipDate := now.AddDate(0, 0, 1).Format(`20060102`)
+	ret, err := postJSON("api/task/skip?id="+id+"&date="+skipDate, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, skipDate, ret["skip"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), task.Date)
+
+	ret, err = postJSON("api/task/skip?id="+id+"&date="+skipDate, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	_, ok := ret["skip"]
+	assert.False(t, ok)
+
+	ret, err = postJSON("api/task/skip?id="+id+"&date=ooops", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
+
+func TestNextDateWorkingDays(t *testing.T) {
+	check := func(date, repeat, want string) {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(date), url.QueryEscape(repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		if _, err = time.Parse("20060102", next); err != nil && len(want) == 0 {
+			return
+		}
+		assert.Equal(t, want, next, `{%q, %q, %q}`, date, repeat, want)
+	}
+
+	check("20240126", "d 1 bd", "20240129")
+	check("20240122", "d 5 bd", "20240129")
+	check("20240220", "m 16 shift", "20240318")
+	check("20220601", "y shift", "20240603")
+	check("20240126", "d 1 shift", "")
+	check("20240126", "m 15 bd", "")
+
+	ret, err := postJSON("api/holidays?date=20240129", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	check("20240126", "d 1 bd", "20240130")
+
+	ret, err = postJSON("api/holidays?date=20240129", nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	check("20240126", "d 1 bd", "20240129")
+}
+
+func TestNextDateRRule(t *testing.T) {
+	tbl := []struct {
+		date   string
+		repeat string
+		want   string
+	}{
+		{"20240101", "FREQ=DAILY;INTERVAL=10", "20240131"},
+		{"20240101", "FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2", "20240129"},
+		{"20240108", "FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2", "20240205"},
+		{"20240126", "RRULE:FREQ=WEEKLY", "20240202"},
+		{"20240115", "FREQ=MONTHLY", "20240215"},
+		{"20240101", "FREQ=MONTHLY;BYDAY=-1FR", "20240223"},
+		{"20240101", "FREQ=MONTHLY;BYMONTHDAY=-1;BYMONTH=2", "20240229"},
+		{"20230301", "FREQ=YEARLY;INTERVAL=2", "20250301"},
+		{"20240101", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", "20241128"},
+		{"20240101", "freq=daily;until=20240120", ""},
+		{"20240101", "FREQ=DAILY;COUNT=3;UNTIL=20240301", ""},
+		{"20240101", "FREQ=HOURLY", ""},
+		{"20240101", "BYDAY=MO", ""},
+		{"20240101", "FREQ=WEEKLY;BYDAY=2MO", ""},
+		{"20240101", "BYDAY=-1FR;FREQ=DAILY", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.date, v.repeat, v.want)
+	}
+
+	now := time.Now()
+	m, err := postJSON("api/task", map[string]any{
+		"date":   now.AddDate(0, 0, 7).Format(`20060102`),
+		"title":  "Созвон",
+		"repeat": "FREQ=WEEKLY;BYDAY=XX",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	id := addTask(t, task{
+		date:   now.Format(`20060102`),
+		title:  "Созвон",
+		repeat: "FREQ=DAILY;COUNT=2",
+	})
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+}
+
+func TestNextDates(t *testing.T) {
+	getDates := func(query string) ([]string, map[string]any) {
+		body, err := getBody("api/nextdates?" + query)
+		assert.NoError(t, err)
+		var dates []string
+		if err = json.Unmarshal(body, &dates); err == nil {
+			return dates, nil
+		}
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		return nil, m
+	}
+
+	dates, _ := getDates("now=20240126&date=20240126&repeat=" + url.QueryEscape("d 7") + "&count=3")
+	assert.Equal(t, []string{"20240202", "20240209", "20240216"}, dates)
+
+	dates, _ = getDates("now=20240126&date=20240126&repeat=" + url.QueryEscape("w 1,3") + "&to=20240207")
+	assert.Equal(t, []string{"20240129", "20240131", "20240205", "20240207"}, dates)
+
+	dates, _ = getDates("now=20240126&date=20240126&repeat=" + url.QueryEscape("d 1 count 2") + "&count=10")
+	assert.Equal(t, []string{"20240127", "20240128"}, dates)
+
+	dates, _ = getDates("now=20240126&date=20240126&repeat=" + url.QueryEscape("d 1 until 20240128"))
+	assert.Equal(t, []string{"20240127", "20240128"}, dates)
+
+	_, m := getDates("now=20240126&date=20240126&repeat=" + url.QueryEscape("d 1") + "&count=1000")
+	assert.NotEmpty(t, m["error"])
+	_, m = getDates("date=20240126&repeat=ooops")
+	assert.NotEmpty(t, m["error"])
+
+	// без now отсчёт идёт от сегодняшней даты в поясе tz или в поясе сервера
+	for _, tz := range []string{"", "Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		loc := time.Local
+		if tz != "" {
+			var err error
+			loc, err = time.LoadLocation(tz)
+			assert.NoError(t, err)
+		}
+		tomorrow := time.Now().In(loc).AddDate(0, 0, 1).Format("20060102")
+		dates, _ = getDates("date=20240126&repeat=" + url.QueryEscape("d 1") + "&count=1&tz=" + url.QueryEscape(tz))
+		assert.Equal(t, []string{tomorrow}, dates, tz)
+	}
+	_, m = getDates("date=20240126&repeat=" + url.QueryEscape("d 1") + "&tz=Mars/Olympus")
+	assert.NotEmpty(t, m["error"])
+}
+
+func TestRepeatDescription(t *testing.T) {
+	describe := func(repeat, lang string) map[string]any {
+		req, err := http.NewRequest(http.MethodGet, getURL("api/repeat/validate?repeat="+url.QueryEscape(repeat)), nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		var m map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+		return m
+	}
+
+	tbl := []struct {
+		repeat string
+		lang   string
+		want   string
+	}{
+		{"d 1", "ru-RU,ru;q=0.9", "каждый день"},
+		{"d 5", "ru", "каждые 5 дней"},
+		{"d 21", "ru", "каждый 21 день"},
+		{"d 7", "en-US,en;q=0.9", "every 7 days"},
+		{"d 5 bd", "en", "every 5 working days"},
+		{"y", "en", "every year"},
+		{"w 1,3", "ru", "каждую неделю: понедельник, среда"},
+		{"w 7", "en", "every week on Sunday"},
+		{"m -1,15 2,8", "ru", "каждый месяц, числа: последний день, 15; месяцы: февраль, август"},
+		{"m -1,15 2,8", "en", "every month on: last day, 15 in February, August"},
+		{"m 2tue,-1fri", "ru", "каждый месяц: 2-й вторник, последняя пятница"},
+		{"m 2tue,-1fri", "en", "every month on the 2nd Tuesday, last Friday"},
+		{"w 1 /2 until 20271231", "ru", "каждую неделю: понедельник, раз в 2 недели, до 31.12.2027"},
+		{"m 15 shift count 3", "en", "every month on: 15, moved to the next working day if it falls on a day off, 3 times"},
+		{"FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2", "en", "every 2 weeks on Monday, Wednesday"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=2", "ru", "каждый месяц: последняя пятница, 2 раза"},
+		{"d 7", "de, en;q=0.5, ru;q=0.7", "каждые 7 дней"},
+		{"FREQ=WEEKLY", "ru", "каждую неделю"},
+		{"FREQ=WEEKLY;INTERVAL=21", "ru", "каждую 21 неделю"},
+		{"FREQ=MONTHLY;INTERVAL=21", "ru", "каждый 21 месяц"},
+		{"min 21", "ru", "каждую 21 минуту"},
+		{"h 1", "ru", "каждый час"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-3,10", "en", "every month on 3rd day from the end, day 10"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "en", "every month on last day"},
+	}
+	for _, v := range tbl {
+		m := describe(v.repeat, v.lang)
+		assert.Equal(t, v.want, m["repeat_text"], `{%q, %q}`, v.repeat, v.lang)
+	}
+
+	m := describe("ooops", "en")
+	assert.NotEmpty(t, m["error"])
+
+	id := addTask(t, task{
+		date:   time.Now().Format(`20060102`),
+		title:  "Описание правила",
+		repeat: "d 3",
+	})
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]string
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "каждые 3 дня", task["repeat_text"])
+}
+
+func TestSkipLimits(t *testing.T) {
+	// пропуск целого дня у правила min 1 — это больше тысячи шагов вложенного правила
+	now := time.Date(2024, 1, 26, 23, 59, 0, 0, time.UTC)
+	next, err := dateutil.NextDate(now, now, "min 1", "20240127", "20240128")
+	require.NoError(t, err)
+	assert.Equal(t, "20240129T0000", next)
+
+	// правило, которое не уходит дальше исключённой даты, даёт ошибку, а не бесконечный поиск
+	stuck, err := parser.NewSkipRepeat(fixedRule{time.Date(2024, 1, 27, 12, 0, 0, 0, time.UTC)}, []string{"20240127"})
+	require.NoError(t, err)
+	_, err = stuck.GetNextDate(now, now)
+	assert.Error(t, err)
+
+	// даты-исключения из запроса проверяются и у задачи без правила повторения
+	task := models.Task{Title: "Без повторения", Skip: "20240127"}
+	assert.Error(t, task.ValidateAndNormalizeDate())
+	task = models.Task{Title: "С повторением", Repeat: "d 1", Skip: "20240128,ooops"}
+	assert.Error(t, task.ValidateAndNormalizeDate())
+	task = models.Task{Title: "С повторением", Repeat: "d 1", Skip: "20240128,20240127,20240128"}
+	require.NoError(t, task.ValidateAndNormalizeDate())
+	assert.Equal(t, "20240127,20240128", task.Skip)
+
+	// список дат-исключений ограничен, чтобы поместиться в столбец skip
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dates := make([]string, models.MaxSkipDates+1)
+	for i := range dates {
+		dates[i] = day.AddDate(0, 0, i).Format(`20060102`)
+	}
+	task = models.Task{Title: "Много исключений", Repeat: "d 1", Skip: strings.Join(dates, ",")}
+	assert.Error(t, task.ValidateAndNormalizeDate())
+	task.Skip = strings.Join(dates[:models.MaxSkipDates], ",")
+	require.NoError(t, task.ValidateAndNormalizeDate())
+	assert.LessOrEqual(t, len(task.Skip), 1024)
+	assert.Error(t, task.AddSkipDate(dates[models.MaxSkipDates]))
+}
+
+func TestShiftNoDrift(t *testing.T) {
+	// 1 января 2028 — суббота, дата переносится на понедельник 3 января,
+	// но следующий год считается от 1 января, а не от перенесённой даты
+	next, base, err := dateutil.NextDateWithBase(time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "y shift")
+	require.NoError(t, err)
+	assert.Equal(t, "20280103", next)
+	assert.Equal(t, "20280101", base)
+	next, base, err = dateutil.NextDateWithBase(time.Date(2028, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC), "y shift")
+	require.NoError(t, err)
+	assert.Equal(t, "20290101", next)
+	assert.Equal(t, next, base)
+
+	// дата по правилу хранится в задаче, поэтому выполнение не сдвигает задачу дальше
+	conn, err := db.Open(filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, db.MigrateUp(conn))
+	for name, store := range map[string]repo.TaskStore{
+		"sqlite": repo.NewTasksRepository(conn),
+		"memory": repo.NewMemoryStore(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			id, err := store.AddTask(models.Task{Date: "20270101", Title: "Новый год", Repeat: "y shift"})
+			require.NoError(t, err)
+			var dates []string
+			for i := 0; i < 3; i++ {
+				next, err := store.PostTaskDone(id, "")
+				require.NoError(t, err)
+				dates = append(dates, next.Date)
+			}
+			assert.Equal(t, []string{"20280103", "20290101", "20300101"}, dates)
+			task, err := store.GetTask(id)
+			require.NoError(t, err)
+			assert.Equal(t, "20300101", task.Date)
+			assert.Empty(t, task.RuleDate)
+		})
+	}
+}
+
+// fixedRule — правило, которое всегда возвращает одну и ту же дату
+type fixedRule struct {
+	date time.Time
+}
+
+func (r fixedRule) GetNextDate(now time.Time, date time.Time) (time.Time, error) { return r.date, nil }
+
+func (r fixedRule) Describe(lang string) string { return "" }
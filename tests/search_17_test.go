@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+	"github.com/wisdomdevil/go_final_project/internal/models"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	if !Search {
+		return
+	}
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	often := addTask(t, task{
+		date:    date,
+		title:   "Квазарный отчёт",
+		comment: "Квазарный отчёт про квазарный телескоп",
+	})
+	rare := addTask(t, task{
+		date:    date,
+		title:   "Написать письмо",
+		comment: "Упомянуть квазарный отчёт",
+	})
+
+	// задача, где слово встречается чаще, выше в выдаче, найденные слова подсвечены
+	tasks := getTasks(t, url.QueryEscape("КВАЗАРНЫЙ"))
+	if assert.Len(t, tasks, 2) {
+		assert.Equal(t, often, tasks[0]["id"])
+		assert.Equal(t, rare, tasks[1]["id"])
+		assert.Contains(t, tasks[0]["snippet"], "<mark>")
+	}
+
+	// слово ищется целиком, слово* — по началу
+	assert.Empty(t, getTasks(t, url.QueryEscape("квазар")))
+	assert.Len(t, getTasks(t, url.QueryEscape("квазар*")), 2)
+
+	// фраза в кавычках — слова подряд
+	tasks = getTasks(t, url.QueryEscape(`"упомянуть квазарный"`))
+	if assert.Len(t, tasks, 1) {
+		assert.Equal(t, rare, tasks[0]["id"])
+	}
+	assert.Empty(t, getTasks(t, url.QueryEscape(`"квазарный упомянуть"`)))
+
+	// поиск по изменённому тексту
+	_, err := postJSON("api/task", map[string]any{
+		"id":    rare,
+		"date":  date,
+		"title": "Написать письмо",
+	}, "PUT")
+	assert.NoError(t, err)
+	assert.Len(t, getTasks(t, url.QueryEscape("квазар*")), 1)
+}
+
+func TestSnippetEscaped(t *testing.T) {
+	testSnippetEscaped(t, openSQLStore(t, filepath.Join(t.TempDir(), "scheduler.db")))
+}
+
+// testSnippetEscaped проверяет фрагмент найденной задачи в хранилище на SQL: SQLite или PostgreSQL
+func testSnippetEscaped(t *testing.T, store repo.TaskStore) {
+	_, err := store.AddTask(models.Task{
+		Date:    time.Now().Format(`20060102`),
+		Title:   `<img src=x onerror="alert(1)"> квазарный отчёт`,
+		Comment: "<script>alert(2)</script>",
+	})
+	require.NoError(t, err)
+
+	// текст задачи во фрагменте экранирован, тегами остаются только <mark>
+	filter, err := repo.QueryDataFromString("квазарный")
+	require.NoError(t, err)
+	tasks, _, err := store.SearchTasks(filter, repo.Page{})
+	require.NoError(t, err)
+	if assert.Len(t, tasks, 1) {
+		snippet := tasks[0].Snippet
+		assert.Contains(t, snippet, "<mark>квазарный</mark>")
+		assert.Contains(t, snippet, "&lt;img")
+		assert.NotContains(t, snippet, "<img")
+		assert.NotContains(t, snippet, "<script")
+	}
+}
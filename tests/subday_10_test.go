@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDateSubDay(t *testing.T) {
+	tbl := []struct {
+		now    string
+		date   string
+		repeat string
+		want   string
+	}{
+		{"20240126T1000", "20240126T0900", "h 4", "20240126T1300"},
+		{"20240126T1000", "20240126T0900", "h 1", "20240126T1100"},
+		{"20240126T1000", "20240125T2300", "h 24", "20240126T2300"},
+		{"20240126T1000", "20240126T0940", "min 30", "20240126T1010"},
+		{"20240126T1000", "20240126T1100", "min 15", "20240126T1115"},
+		{"20240126T1000", "20240126T0900", "t 09:00,18:00", "20240126T1800"},
+		{"20240126T1900", "20240126T0900", "t 18:00,09:00", "20240127T0900"},
+		{"20240126T1000", "20240126T0900", "h 169", ""},
+		{"20240126T1000", "20240126T0900", "min 0", ""},
+		{"20240126T1000", "20240126T0900", "t 25:00", ""},
+		{"20240126T1000", "20240126T0900", "h", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=%s&date=%s&repeat=%s",
+			v.now, v.date, url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102T1504", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.now, v.date, v.repeat)
+	}
+}
+
+func TestDoneSubDay(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now().UTC()
+	start := now.Add(time.Hour).Truncate(time.Minute)
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     start.Format(`20060102`),
+		"title":    "Размяться",
+		"repeat":   "h 3",
+		"time":     start.Format("15:04"),
+		"timezone": "UTC",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	next := start.Add(3 * time.Hour)
+	assert.Equal(t, next.Format(`20060102`), task.Date)
+	assert.Equal(t, next.Format("15:04"), task.Time)
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimezone(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	assert.NoError(t, err)
+	today := time.Now().In(loc).Format(`20060102`)
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":     "today",
+		"title":    "Созвон с Кирибати",
+		"time":     "09:30",
+		"timezone": "Pacific/Kiritimati",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, today, task.Date)
+	assert.Equal(t, "09:30", task.Time)
+	assert.Equal(t, "Pacific/Kiritimati", task.Timezone)
+
+	utcToday := time.Now().UTC()
+	ret, err = postJSON("api/task", map[string]any{
+		"date":     utcToday.Format(`20060102`),
+		"title":    "Полночь по UTC",
+		"repeat":   "d 1",
+		"time":     "00:00",
+		"timezone": "UTC",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	if utcToday.Format("15:04") > "00:00" {
+		assert.Equal(t, utcToday.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	}
+
+	for _, v := range []map[string]any{
+		{"title": "Неверное время", "time": "25:00"},
+		{"title": "Неверный пояс", "timezone": "Mars/Olympus"},
+	} {
+		m, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"])
+	}
+}
+
+func TestTaskAnchor(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	newTask := func(anchor string, date time.Time) string {
+		ret, err := postJSON("api/task", map[string]any{
+			"date":   date.Format(`20060102`),
+			"title":  "Полить цветы",
+			"repeat": "d 7",
+			"anchor": anchor,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		return fmt.Sprint(ret["id"])
+	}
+	taskDate := func(id string) string {
+		var task Task
+		err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		return task.Date
+	}
+
+	schedule := newTask("", now.AddDate(0, 0, 3))
+	completion := newTask("completion", now.AddDate(0, 0, 3))
+	for _, id := range []string{schedule, completion} {
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	assert.Equal(t, now.AddDate(0, 0, 10).Format(`20060102`), taskDate(schedule))
+	assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), taskDate(completion))
+
+	overdue := newTask("completion", now.AddDate(0, 0, -3))
+	assert.Equal(t, now.Format(`20060102`), taskDate(overdue))
+
+	m, err := postJSON("api/task", map[string]any{
+		"title":  "Неверный режим",
+		"repeat": "d 7",
+		"anchor": "sometimes",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wisdomdevil/go_final_project/internal/db"
+	"github.com/wisdomdevil/go_final_project/internal/db/repo"
+)
+
+func TestTokenLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+
+	var signin map[string]string
+	status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"password": "123456"}, &signin)
+	require.Equal(t, http.StatusOK, status)
+	access, refresh := signin["token"], signin["refresh_token"]
+	require.NotEmpty(t, refresh)
+	expires, err := time.Parse(time.RFC3339, signin["expires_at"])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, time.Minute)
+
+	// постоянный токен без срока действия и просроченный токен не принимаются
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = testKey.ID
+		signed, err := token.SignedString(testKey.Secret)
+		require.NoError(t, err)
+		return signed
+	}
+	for _, claims := range []jwt.MapClaims{
+		{"sub": "1", "typ": "access", "jti": "a"},
+		{"sub": "1", "typ": "access", "jti": "b", "exp": time.Now().Add(-time.Minute).Unix()},
+	} {
+		ts.token = sign(claims)
+		assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+	}
+
+	// токен обновления не заменяет токен доступа
+	ts.token = refresh
+	assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+	ts.token = access
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+
+	// обновление выдаёт новую пару, старый токен обновления повторно не действует
+	var refreshed map[string]string
+	status = ts.do(t, http.MethodPost, "/api/refresh", map[string]any{"refresh_token": refresh}, &refreshed)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, refreshed["token"])
+	assert.NotEqual(t, refresh, refreshed["refresh_token"])
+	status = ts.do(t, http.MethodPost, "/api/refresh", map[string]any{"refresh_token": refresh}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status = ts.do(t, http.MethodPost, "/api/refresh", map[string]any{"refresh_token": access}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// выход отзывает токен доступа и токен обновления
+	ts.token = refreshed["token"]
+	status = ts.do(t, http.MethodPost, "/api/signout", map[string]any{"refresh_token": refreshed["refresh_token"]}, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+	status = ts.do(t, http.MethodPost, "/api/refresh", map[string]any{"refresh_token": refreshed["refresh_token"]}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, http.StatusUnauthorized, ts.do(t, http.MethodPost, "/api/signout", nil, nil))
+
+	// токен первого входа остаётся действующим
+	ts.token = access
+	assert.Equal(t, http.StatusOK, ts.do(t, http.MethodGet, "/api/tasks", nil, nil))
+}
+
+func TestTokenDenylist(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, db.MigrateUp(conn))
+
+	for name, store := range map[string]repo.TokenStore{
+		"sqlite": repo.NewTasksRepository(conn),
+		"memory": repo.NewMemoryStore(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.RevokeToken("old", time.Now().Add(-time.Hour)))
+			require.NoError(t, store.RevokeToken("live", time.Now().Add(time.Hour)))
+			// повторный отзыв сообщает, что токен уже отозван: так обновление по одному токену проходит один раз
+			assert.ErrorIs(t, store.RevokeToken("live", time.Now().Add(time.Hour)), repo.ErrTokenRevoked)
+
+			revoked, err := store.IsTokenRevoked("live")
+			require.NoError(t, err)
+			assert.True(t, revoked)
+			revoked, err = store.IsTokenRevoked("other")
+			require.NoError(t, err)
+			assert.False(t, revoked)
+		})
+	}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	ts := newTestServer(t)
+
+	var signin map[string]string
+	status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"password": "123456"}, &signin)
+	require.Equal(t, http.StatusOK, status)
+	body, err := json.Marshal(map[string]string{"refresh_token": signin["refresh_token"]})
+	require.NoError(t, err)
+
+	// из одновременных обновлений по одному токену успешно только одно
+	const requests = 10
+	var (
+		wg sync.WaitGroup
+		ok atomic.Int32
+	)
+	start := make(chan struct{})
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := ts.Client().Post(ts.URL+"/api/refresh", "application/json", bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				ok.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int32(1), ok.Load())
+}
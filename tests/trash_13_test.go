@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTrash(t *testing.T) []map[string]string {
+	body, err := requestJSON("api/trash", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["tasks"]
+}
+
+func inTasks(tasks []map[string]string, id string) bool {
+	for _, task := range tasks {
+		if task["id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTrash(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	id := addTask(t, task{
+		date:  now.Format(`20060102`),
+		title: "Задача в корзину",
+	})
+	ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	// строка осталась в БД с отметкой удаления, но в списке и поиске её нет
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, task.DeletedAt)
+	notFoundTask(t, id)
+	assert.False(t, inTasks(getTasks(t, ""), id))
+	assert.False(t, inTasks(getTasks(t, "корзину"), id))
+	assert.True(t, inTasks(getTrash(t), id))
+
+	ret, err = postJSON("api/trash/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.False(t, inTasks(getTrash(t), id))
+	assert.True(t, inTasks(getTasks(t, "корзину"), id))
+
+	ret, err = postJSON("api/trash?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"], "нельзя окончательно удалить задачу не из корзины")
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	ret, err = postJSON("api/trash?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.False(t, inTasks(getTrash(t), id))
+	assert.Error(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+
+	ret, err = postJSON("api/trash/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
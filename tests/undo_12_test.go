@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// undoToken выполняет запрос и возвращает токен отмены из заголовка X-Undo-Token
+func undoToken(t *testing.T, apipath string, method string) string {
+	req, err := http.NewRequest(method, getURL(apipath), nil)
+	assert.NoError(t, err)
+	token, err := authToken()
+	assert.NoError(t, err)
+	if len(token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp.Header.Get("X-Undo-Token")
+}
+
+func TestUndo(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	id := addTask(t, task{
+		date:  now.Format(`20060102`),
+		title: "Разовая задача",
+	})
+	token := undoToken(t, "api/task/done?id="+id, http.MethodPost)
+	assert.NotEmpty(t, token)
+	notFoundTask(t, id)
+	assert.Len(t, getHistory(t, id), 1)
+
+	ret, err := postJSON("api/task/undo?id="+id+"&token="+token, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, id, fmt.Sprint(ret["id"]))
+	assert.Equal(t, "Разовая задача", ret["title"])
+	assert.Empty(t, getHistory(t, id))
+
+	// токен одноразовый
+	ret, err = postJSON("api/task/undo?id="+id+"&token="+token, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	id = addTask(t, task{
+		date:   now.Format(`20060102`),
+		title:  "Повторяющаяся задача",
+		repeat: "d 3",
+	})
+	token = undoToken(t, "api/task/done?id="+id, http.MethodPost)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), task.Date)
+
+	// отменяется только последняя операция
+	ret, err = postJSON("api/task/undo?id="+id+"&token=ooops", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	deleteToken := undoToken(t, "api/task?id="+id, http.MethodDelete)
+	notFoundTask(t, id)
+	ret, err = postJSON("api/task/undo?id="+id+"&token="+token, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/task/undo?id="+id+"&token="+deleteToken, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), task.Date)
+}
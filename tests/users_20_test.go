@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signinAs входит в тестовый сервер под пользователем login
+func (ts *testServer) signinAs(t *testing.T, login, password string) {
+	var signin map[string]string
+	ts.token = ""
+	status := ts.do(t, http.MethodPost, "/api/signin", map[string]any{"login": login, "password": password}, &signin)
+	require.Equal(t, http.StatusOK, status, signin)
+	ts.token = signin["token"]
+}
+
+func TestUsersIsolation(t *testing.T) {
+	ts := newTestServer(t)
+	adminToken := ts.token
+	today := time.Now().Format(`20060102`)
+
+	ts.token = ""
+	for _, login := range []string{"alice", "bob"} {
+		status := ts.do(t, http.MethodPost, "/api/signup", map[string]any{"login": login, "password": login + "-secret"}, nil)
+		require.Equal(t, http.StatusCreated, status)
+	}
+	status := ts.do(t, http.MethodPost, "/api/signup", map[string]any{"login": "alice", "password": "another-secret"}, nil)
+	assert.Equal(t, http.StatusConflict, status)
+	status = ts.do(t, http.MethodPost, "/api/signup", map[string]any{"login": "carol", "password": "123"}, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	status = ts.do(t, http.MethodPost, "/api/signin", map[string]any{"login": "alice", "password": "bob-secret"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	ts.signinAs(t, "alice", "alice-secret")
+	var created map[string]int
+	status = ts.do(t, http.MethodPost, "/api/task", map[string]any{"date": today, "title": "Задача Алисы"}, &created)
+	require.Equal(t, http.StatusCreated, status)
+	aliceTask := fmt.Sprint(created["id"])
+
+	var user map[string]any
+	ts.do(t, http.MethodGet, "/api/user", nil, &user)
+	assert.Equal(t, "alice", user["login"])
+	assert.Equal(t, false, user["admin"])
+
+	// Боб не видит задачи Алисы и не может их менять
+	ts.signinAs(t, "bob", "bob-secret")
+	var list map[string][]map[string]string
+	ts.do(t, http.MethodGet, "/api/tasks", nil, &list)
+	assert.Empty(t, list["tasks"])
+	ts.do(t, http.MethodGet, "/api/tasks?search=Алисы", nil, &list)
+	assert.Empty(t, list["tasks"])
+	status = ts.do(t, http.MethodGet, "/api/task?id="+aliceTask, nil, nil)
+	assert.NotEqual(t, http.StatusOK, status)
+	ts.do(t, http.MethodDelete, "/api/task?id="+aliceTask, nil, nil)
+	ts.do(t, http.MethodPost, "/api/task/done?id="+aliceTask, nil, nil)
+
+	// обычный пользователь не создаёт пользователей и не меняет общий календарь
+	status = ts.do(t, http.MethodPost, "/api/users", map[string]any{"login": "mallory", "password": "mallory-secret", "admin": true}, nil)
+	assert.Equal(t, http.StatusForbidden, status)
+	status = ts.do(t, http.MethodPost, "/api/holidays?date="+today+"&name=x", nil, nil)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	ts.signinAs(t, "alice", "alice-secret")
+	ts.do(t, http.MethodGet, "/api/tasks", nil, &list)
+	if assert.Len(t, list["tasks"], 1) {
+		assert.Equal(t, "Задача Алисы", list["tasks"][0]["title"])
+		assert.Equal(t, today, list["tasks"][0]["date"])
+	}
+
+	// администратор видит только свои задачи, но может создать другого администратора
+	ts.token = adminToken
+	ts.do(t, http.MethodGet, "/api/tasks", nil, &list)
+	assert.Empty(t, list["tasks"])
+	status = ts.do(t, http.MethodPost, "/api/users", map[string]any{"login": "root", "password": "root-secret", "admin": true}, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	ts.signinAs(t, "root", "root-secret")
+	ts.do(t, http.MethodGet, "/api/user", nil, &user)
+	assert.Equal(t, true, user["admin"])
+}